@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+/*
+--------------------ABI--------------------
+*/
+type function_abi_struct struct {
+	Name                string `json:"name"`
+	Visibility          string `json:"visibility"`
+	Is_entry            bool   `json:"is_entry"`
+	Generic_type_params []struct {
+		Constraints []string `json:"constraints"`
+	} `json:"generic_type_params"`
+	Params []string `json:"params"`
+}
+
+// fetch_function_abi reads the function from the module abi, function is address::module::name
+func fetch_function_abi(client client_struct, function string) (function_abi_struct, error) {
+
+	parts := strings.Split(function, "::")
+	if len(parts) != 3 {
+		return function_abi_struct{}, errors.New("abi: function must be address::module::name")
+	}
+
+	status, body, err := node_get(client.accounts + parts[0] + "/module/" + parts[1])
+	if err != nil {
+		return function_abi_struct{}, err
+	}
+	if status == 404 {
+		return function_abi_struct{}, fmt.Errorf("abi: module %s::%s not found", parts[0], parts[1])
+	}
+
+	var module struct {
+		Abi struct {
+			Exposed_functions []function_abi_struct `json:"exposed_functions"`
+		} `json:"abi"`
+	}
+	if err = json.Unmarshal(body, &module); err != nil {
+		return function_abi_struct{}, errors.New("abi: error decode module")
+	}
+
+	for _, abi := range module.Abi.Exposed_functions {
+		if abi.Name == parts[2] {
+			if !abi.Is_entry {
+				return function_abi_struct{}, fmt.Errorf("abi: %s is not an entry function", function)
+			}
+			return abi, nil
+		}
+	}
+
+	return function_abi_struct{}, fmt.Errorf("abi: function %s not found", function)
+}
+
+var generic_type_param = regexp.MustCompile(`\bT(\d+)\b`)
+
+// argument_types are the params without signers, generic T0, T1.. replaced by the type arguments
+func (abi function_abi_struct) argument_types(type_arguments []string) []string {
+
+	var types []string
+	for _, param := range abi.Params {
+		if param == "signer" || param == "&signer" {
+			continue
+		}
+
+		param = generic_type_param.ReplaceAllStringFunc(param, func(match string) string {
+			i, _ := strconv.Atoi(match[1:])
+			if i < len(type_arguments) {
+				return type_arguments[i]
+			}
+			return match
+		})
+		types = append(types, param)
+	}
+
+	return types
+}
+
+// validate checks the payload against the abi and types its arguments
+func (abi function_abi_struct) validate(payload *payload_struct) error {
+
+	if len(payload.TypeArguments) != len(abi.Generic_type_params) {
+		return fmt.Errorf("abi: %s expects %d type arguments, got %d", payload.Function, len(abi.Generic_type_params), len(payload.TypeArguments))
+	}
+
+	payload.ArgumentTypes = abi.argument_types(payload.TypeArguments)
+
+	arguments, err := move_vector(payload.Arguments)
+	if err != nil {
+		return errors.New("abi: arguments must be a json array")
+	}
+	if len(arguments) != len(payload.ArgumentTypes) {
+		return fmt.Errorf("abi: %s expects %d arguments (%s), got %d", payload.Function, len(payload.ArgumentTypes), strings.Join(payload.ArgumentTypes, ", "), len(arguments))
+	}
+
+	for i, argument := range arguments {
+		tag, err := parse_type_tag(payload.ArgumentTypes[i])
+		if err != nil {
+			return err
+		}
+
+		var s bcs_serializer
+		if err := encode_move_value(&s, tag, argument); err != nil {
+			return fmt.Errorf("abi: argument %d (%s): %w", i, payload.ArgumentTypes[i], err)
+		}
+	}
+
+	return nil
+}
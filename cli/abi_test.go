@@ -0,0 +1,181 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const test_module_abi = `{"bytecode":"0x","abi":{"address":"0xabc","name":"minting","exposed_functions":[
+	{"name":"mint","visibility":"public","is_entry":true,"generic_type_params":[],"params":["&signer","u64","vector<u8>","address","0x1::string::String"]},
+	{"name":"swap","visibility":"public","is_entry":true,"generic_type_params":[{"constraints":[]},{"constraints":[]}],"params":["&signer","u64","vector<T1>","0x1::option::Option<T0>","address"]},
+	{"name":"claim","visibility":"public","is_entry":true,"generic_type_params":[],"params":["signer","bool"]},
+	{"name":"price","visibility":"public","is_entry":false,"generic_type_params":[],"params":["u64"]}
+]}}`
+
+// fake_module_node serves the abi of 0xabc::minting
+func fake_module_node(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/accounts/0xabc/module/minting" {
+			w.WriteHeader(404)
+			w.Write([]byte(`{"message":"Module not found","error_code":"module_not_found"}`))
+			return
+		}
+		w.Write([]byte(test_module_abi))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestArgumentTypes(t *testing.T) {
+
+	tests := []struct {
+		name           string
+		params         []string
+		type_arguments []string
+		types          []string
+	}{
+		{"signers dropped", []string{"&signer", "u64", "address"}, nil, []string{"u64", "address"}},
+		{"signer by value", []string{"signer", "bool"}, nil, []string{"bool"}},
+		{"no arguments", []string{"&signer"}, nil, nil},
+		{"generics", []string{"&signer", "T0", "vector<T1>", "0x1::option::Option<T0>"},
+			[]string{"u64", "0x1::string::String"}, []string{"u64", "vector<0x1::string::String>", "0x1::option::Option<u64>"}},
+		// T10 is not T1 followed by 0
+		{"two digit generic", []string{"T10", "T1"}, []string{"u8", "u16"}, []string{"T10", "u16"}},
+		{"nested generics", []string{"vector<vector<T0>>"}, []string{"0x1::aptos_coin::AptosCoin"}, []string{"vector<vector<0x1::aptos_coin::AptosCoin>>"}},
+		// struct names holding a T are left alone
+		{"struct names", []string{"0x1::object::Object<0xabc::token::T0ken>"}, []string{"u8"}, []string{"0x1::object::Object<0xabc::token::T0ken>"}},
+	}
+
+	for _, test := range tests {
+		abi := function_abi_struct{Params: test.params}
+		if types := abi.argument_types(test.type_arguments); !reflect.DeepEqual(types, test.types) {
+			t.Errorf("%s: %v, want %v", test.name, types, test.types)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+
+	mint := function_abi_struct{Name: "mint", Is_entry: true, Params: []string{"&signer", "u64", "vector<u8>", "address", "0x1::string::String"}}
+	swap := function_abi_struct{Name: "swap", Is_entry: true, Params: []string{"&signer", "T0", "vector<T1>"}}
+	swap.Generic_type_params = make([]struct {
+		Constraints []string `json:"constraints"`
+	}, 2)
+
+	tests := []struct {
+		name           string
+		abi            function_abi_struct
+		type_arguments []string
+		arguments      string
+		err            string
+	}{
+		{"valid", mint, nil, `["2", "0x0102", "0x1", "bear"]`, ""},
+		{"json numbers", mint, nil, `[2, [1, 2], "0x1", "bear"]`, ""},
+		{"missing argument", mint, nil, `["2", "0x0102", "0x1"]`, "expects 4 arguments (u64, vector<u8>, address, 0x1::string::String), got 3"},
+		{"extra argument", mint, nil, `["2", "0x0102", "0x1", "bear", "1"]`, "expects 4 arguments"},
+		{"not an array", mint, nil, `{"amount": "2"}`, "arguments must be a json array"},
+		{"wrong integer", mint, nil, `["two", "0x0102", "0x1", "bear"]`, "argument 0 (u64)"},
+		{"negative integer", mint, nil, `["-1", "0x0102", "0x1", "bear"]`, "argument 0 (u64)"},
+		{"wrong bytes", mint, nil, `["2", "0xzz", "0x1", "bear"]`, "argument 1 (vector<u8>)"},
+		{"wrong address", mint, nil, `["2", "0x0102", 1, "bear"]`, "argument 2 (address)"},
+		{"wrong string", mint, nil, `["2", "0x0102", "0x1", 7]`, "argument 3 (0x1::string::String)"},
+		{"generics", swap, []string{"u8", "bool"}, `["255", [true, "false"]]`, ""},
+		{"generic overflow", swap, []string{"u8", "bool"}, `["256", [true]]`, "argument 0 (u8)"},
+		{"generic wrong type", swap, []string{"u8", "bool"}, `["1", ["yes"]]`, "argument 1 (vector<bool>)"},
+		{"missing type argument", swap, []string{"u8"}, `["1", [true]]`, "expects 2 type arguments, got 1"},
+	}
+
+	for _, test := range tests {
+		var arguments interface{}
+		if err := unmarshal_arguments([]byte(test.arguments), &arguments); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		type_arguments := test.type_arguments
+		if type_arguments == nil {
+			type_arguments = []string{}
+		}
+		payload := payload_struct{Function: "0xabc::minting::" + test.abi.Name, TypeArguments: type_arguments, Arguments: arguments}
+
+		err := test.abi.validate(&payload)
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%s: %v", test.name, err)
+		case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+			t.Errorf("%s: error %v, want %q", test.name, err, test.err)
+		}
+	}
+
+	// the argument types are kept for bcs encoding
+	payload := payload_struct{Function: "0xabc::minting::swap", TypeArguments: []string{"u8", "bool"}, Arguments: []interface{}{"1", []interface{}{true}}}
+	if err := swap.validate(&payload); err != nil || !reflect.DeepEqual(payload.ArgumentTypes, []string{"u8", "vector<bool>"}) {
+		t.Errorf("argument types %v %v", payload.ArgumentTypes, err)
+	}
+}
+
+func TestLoadEntryFunction(t *testing.T) {
+
+	Config := test_broadcast_config(fake_module_node(t))
+	dir := t.TempDir()
+
+	tests := []struct {
+		name  string
+		file  string
+		err   string
+		valid string
+	}{
+		{"valid", `{"function": "0xabc::minting::mint", "type_arguments": [], "arguments": ["18446744073709551615", "0x01", "0x2", "bear"]}`, "", ""},
+		{"generics", `{"function": "0xabc::minting::swap", "type_arguments": ["0x1::aptos_coin::AptosCoin", "u8"], "arguments": ["1", ["2"], {"vec": []}, "0x3"]}`, "", ""},
+		{"generic wrong type", `{"function": "0xabc::minting::swap", "type_arguments": ["0x1::aptos_coin::AptosCoin", "u8"], "arguments": ["1", ["256"], {"vec": []}, "0x3"]}`, "", "argument 1 (vector<u8>)"},
+		// type arguments and arguments may be left out
+		{"no arguments", `{"function": "0xabc::minting::claim"}`, "", "expects 1 arguments (bool), got 0"},
+		{"wrong count", `{"function": "0xabc::minting::claim", "arguments": [true, false]}`, "", "expects 1 arguments (bool), got 2"},
+		{"wrong type", `{"function": "0xabc::minting::claim", "arguments": ["maybe"]}`, "", "argument 0 (bool)"},
+		{"wrong type arguments", `{"function": "0xabc::minting::swap", "type_arguments": ["u8"], "arguments": []}`, "", "expects 2 type arguments, got 1"},
+		{"not an entry function", `{"function": "0xabc::minting::price", "arguments": ["1"]}`, "0xabc::minting::price is not an entry function", ""},
+		{"unknown function", `{"function": "0xabc::minting::burn"}`, "function 0xabc::minting::burn not found", ""},
+		{"unknown module", `{"function": "0xabc::staking::stake"}`, "module 0xabc::staking not found", ""},
+		{"bad function", `{"function": "mint"}`, "function must be address::module::name", ""},
+		{"bad json", `{"function": "0xabc::minting::mint",}`, "error decode", ""},
+	}
+
+	for _, test := range tests {
+		path := filepath.Join(dir, strings.ReplaceAll(test.name, " ", "_")+".json")
+		os.WriteFile(path, []byte(test.file), 0600)
+
+		payload, abi, err := load_entry_function(Config, path)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: error %v, want %q", test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if payload.Type != "entry_function_payload" || payload.TypeArguments == nil || payload.Arguments == nil {
+			t.Errorf("%s: payload %+v", test.name, payload)
+		}
+
+		err = abi.validate(&payload)
+		switch {
+		case test.valid == "" && err != nil:
+			t.Errorf("%s: %v", test.name, err)
+		case test.valid != "" && (err == nil || !strings.Contains(err.Error(), test.valid)):
+			t.Errorf("%s: validate %v, want %q", test.name, err, test.valid)
+		}
+	}
+
+	if _, _, err := load_entry_function(Config, filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("loaded a missing file")
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gookit/color"
+)
+
+/*
+--------------------Balance--------------------
+*/
+const aptos_coin = "0x1::aptos_coin::AptosCoin"
+
+type balance_struct struct {
+	mutex    sync.RWMutex
+	octas    uint64
+	reserved uint64        // prices and max gas of purchases in flight
+	changed  chan struct{} // closed when a reservation is released
+}
+
+func (balance *balance_struct) get() uint64 {
+	balance.mutex.RLock()
+	defer balance.mutex.RUnlock()
+
+	return balance.octas
+}
+
+func (balance *balance_struct) set(octas uint64) bool {
+	balance.mutex.Lock()
+	defer balance.mutex.Unlock()
+
+	changed := balance.octas != octas
+	balance.octas = octas
+	balance.notify()
+
+	return changed
+}
+
+func (balance *balance_struct) String() string {
+	return fmt.Sprintf("%f", float64(balance.get())/100_000_000)
+}
+
+// fetch_balance reads the CoinStore resource, the coin::balance view covers migrated fungible assets
+func fetch_balance(client client_struct, address string) (uint64, error) {
+
+	req, _ := http.NewRequest("GET", client.accounts+address+"/resource/0x1::coin::CoinStore<"+aptos_coin+">", nil)
+	res, err := node_client.Do(req)
+	if err != nil {
+		return 0, errors.New("balance: node request error")
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return 0, errors.New("balance: error read body")
+	}
+
+	if res.StatusCode == 200 {
+		var response struct {
+			Data struct {
+				Coin struct {
+					Value string `json:"value"`
+				} `json:"coin"`
+			} `json:"data"`
+		}
+		if err = json.Unmarshal(body, &response); err != nil {
+			return 0, errors.New("balance: error decode coin store")
+		}
+
+		return strconv.ParseUint(response.Data.Coin.Value, 10, 64)
+	}
+
+	view, _ := json.Marshal(map[string]interface{}{
+		"function":       "0x1::coin::balance",
+		"type_arguments": []string{aptos_coin},
+		"arguments":      []string{address},
+	})
+
+	req, _ = http.NewRequest("POST", client.url+"/view", bytes.NewReader(view))
+	req.Header.Add("Content-Type", "application/json")
+	res, err = node_client.Do(req)
+	if err != nil {
+		return 0, errors.New("balance: node request error")
+	}
+	defer res.Body.Close()
+
+	if body, err = ioutil.ReadAll(res.Body); err != nil {
+		return 0, errors.New("balance: error read body")
+	}
+
+	var response []string
+	if res.StatusCode != 200 || json.Unmarshal(body, &response) != nil || len(response) == 0 {
+		// account without coins yet
+		if res.StatusCode == 404 || res.StatusCode == 400 {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("balance: view status %s", res.Status)
+	}
+
+	return strconv.ParseUint(response[0], 10, 64)
+}
+
+func (Config *config_struct) refresh_balance() {
+
+	octas, err := fetch_balance(Config.node(), Config.wallet.address_str)
+	if err != nil {
+		return
+	}
+
+	if Config.wallet.balance.set(octas) {
+		update_header_balance(Config)
+	}
+
+	// round robin wallets
+	if Config.wallets == nil {
+		return
+	}
+	for _, wallet := range Config.wallets.ready() {
+		if wallet == &Config.wallet {
+			continue
+		}
+		if octas, err := fetch_balance(Config.node(), wallet.address_str); err == nil {
+			wallet.balance.set(octas)
+		}
+	}
+}
+
+// start_balance refreshes the wallet balance in background
+func start_balance(Config *config_struct) {
+	go func() {
+		for {
+			time.Sleep(15000 * time.Millisecond)
+			Config.refresh_balance()
+		}
+	}()
+}
+
+/*
+----------Spend guard----------
+*/
+
+// how long a listing waits for in-flight purchases to free the balance
+const spend_queue_timeout = 10 * time.Second
+
+func (balance *balance_struct) available() uint64 {
+	balance.mutex.RLock()
+	defer balance.mutex.RUnlock()
+
+	if balance.reserved >= balance.octas {
+		return 0
+	}
+
+	return balance.octas - balance.reserved
+}
+
+// reserve holds amount for a purchase, waiting while in-flight purchases could free enough of it
+func (balance *balance_struct) reserve(amount uint64, wait time.Duration) bool {
+
+	deadline := time.After(wait)
+
+	for {
+		balance.mutex.Lock()
+		if balance.reserved+amount <= balance.octas {
+			balance.reserved += amount
+			balance.mutex.Unlock()
+			return true
+		}
+
+		// nothing in flight can make it affordable
+		if amount > balance.octas {
+			balance.mutex.Unlock()
+			return false
+		}
+
+		if balance.changed == nil {
+			balance.changed = make(chan struct{})
+		}
+		changed := balance.changed
+		balance.mutex.Unlock()
+
+		select {
+		case <-changed:
+		case <-deadline:
+			return false
+		}
+	}
+}
+
+// release frees the reservation of a purchase that did not go through
+func (balance *balance_struct) release(amount uint64) {
+	balance.mutex.Lock()
+	defer balance.mutex.Unlock()
+
+	if amount > balance.reserved {
+		amount = balance.reserved
+	}
+	balance.reserved -= amount
+	balance.notify()
+}
+
+// settle moves the reservation of a committed purchase out of the balance until the next refresh
+func (balance *balance_struct) settle(amount uint64) {
+	balance.mutex.Lock()
+	defer balance.mutex.Unlock()
+
+	if amount > balance.reserved {
+		amount = balance.reserved
+	}
+	balance.reserved -= amount
+
+	if amount > balance.octas {
+		amount = balance.octas
+	}
+	balance.octas -= amount
+	balance.notify()
+}
+
+// notify wakes reservations waiting for funds, mutex must be held
+func (balance *balance_struct) notify() {
+	if balance.changed != nil {
+		close(balance.changed)
+		balance.changed = nil
+	}
+}
+
+/*
+----------Header----------
+*/
+
+// rows printed by logo, kept on screen while sniping
+const header_rows = 8
+
+// pin_header keeps the logo on top, the log scrolls below it
+func pin_header(Config *config_struct) {
+	Config.session.header = true
+	fmt.Printf("\0337\033[%d;r\0338", header_rows+1)
+}
+
+func unpin_header(Config *config_struct) {
+	Config.session.header = false
+	fmt.Print("\0337\033[r\0338")
+}
+
+func update_header_balance(Config *config_struct) {
+	if !Config.session.header {
+		return
+	}
+
+	fmt.Printf("\0337\033[%d;1H\033[2K%s\0338", header_rows-1, color.Magenta.Text("Balance: "+Config.wallet.balance.String()))
+}
+
+// update_header_node shows the active node after a failover
+func update_header_node(Config *config_struct) {
+	if !Config.session.header {
+		return
+	}
+
+	fmt.Printf("\0337\033[%d;1H\033[2K%s\0338", header_rows, color.Magenta.Text("Node: "+Config.node().url+" ["+Config.network_name()+"]"))
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fake_balance_node answers the CoinStore resource and the coin::balance view, a zero status is not served
+func fake_balance_node(t *testing.T, store_status int, store string, view_status int, view string) (*httptest.Server, *[]string) {
+	t.Helper()
+
+	var views []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/v1/accounts/0x1234/resource/0x1::coin::CoinStore<") && store_status != 0:
+			w.WriteHeader(store_status)
+			w.Write([]byte(store))
+		case r.URL.Path == "/v1/view" && r.Method == "POST" && view_status != 0:
+			body, _ := ioutil.ReadAll(r.Body)
+			views = append(views, string(body))
+			w.WriteHeader(view_status)
+			w.Write([]byte(view))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &views
+}
+
+const test_resource_not_found = `{"message":"Resource not found","error_code":"resource_not_found"}`
+
+func TestFetchBalance(t *testing.T) {
+
+	tests := []struct {
+		name         string
+		store_status int
+		store        string
+		view_status  int
+		view         string
+		octas        uint64
+		fails        bool
+		viewed       bool
+	}{
+		{"coin store", 200, `{"type":"0x1::coin::CoinStore<0x1::aptos_coin::AptosCoin>","data":{"coin":{"value":"123456789"}}}`, 0, "", 123456789, false, false},
+		// migrated to fungible assets, the CoinStore is gone
+		{"view fallback", 404, test_resource_not_found, 200, `["987654321"]`, 987654321, false, true},
+		// accounts without coins answer 404 or 400 on the view
+		{"no coins 404", 404, test_resource_not_found, 404, `{"message":"Account not found"}`, 0, false, true},
+		{"no coins 400", 404, test_resource_not_found, 400, `{"message":"Invalid input"}`, 0, false, true},
+		{"view error", 404, test_resource_not_found, 500, `{"message":"internal error"}`, 0, true, true},
+		{"bad coin store", 200, `not json`, 0, "", 0, true, false},
+	}
+
+	for _, test := range tests {
+		server, views := fake_balance_node(t, test.store_status, test.store, test.view_status, test.view)
+
+		octas, err := fetch_balance(new_client(server.URL+"/v1", 1), "0x1234")
+		if (err != nil) != test.fails || octas != test.octas {
+			t.Errorf("%s: %d %v, want %d fails %t", test.name, octas, err, test.octas, test.fails)
+		}
+
+		if !test.viewed {
+			if len(*views) != 0 {
+				t.Errorf("%s: %d view calls, want none", test.name, len(*views))
+			}
+			continue
+		}
+		if len(*views) != 1 {
+			t.Errorf("%s: %d view calls, want 1", test.name, len(*views))
+			continue
+		}
+
+		var view struct {
+			Function       string   `json:"function"`
+			Type_arguments []string `json:"type_arguments"`
+			Arguments      []string `json:"arguments"`
+		}
+		json.Unmarshal([]byte((*views)[0]), &view)
+		if view.Function != "0x1::coin::balance" || len(view.Type_arguments) != 1 || view.Type_arguments[0] != aptos_coin || len(view.Arguments) != 1 || view.Arguments[0] != "0x1234" {
+			t.Errorf("%s: view %s", test.name, (*views)[0])
+		}
+	}
+}
+
+func TestReserve(t *testing.T) {
+
+	balance := &balance_struct{octas: 1000}
+
+	if !balance.reserve(600, 0) || balance.available() != 400 {
+		t.Fatalf("first reserve, available %d", balance.available())
+	}
+
+	// more than the whole balance fails without waiting
+	start := time.Now()
+	if balance.reserve(1001, time.Second) {
+		t.Errorf("reserved more than the balance")
+	}
+	if time.Since(start) > 100*time.Millisecond {
+		t.Errorf("waited %s for an amount nothing in flight can free", time.Since(start))
+	}
+
+	// affordable once the purchase in flight is gone, times out while it is not
+	start = time.Now()
+	if balance.reserve(500, 50*time.Millisecond) {
+		t.Errorf("reserved 500 with 400 available")
+	}
+	if waited := time.Since(start); waited < 50*time.Millisecond {
+		t.Errorf("gave up after %s, want the wait", waited)
+	}
+	if balance.available() != 400 {
+		t.Errorf("available %d after timeout, want 400", balance.available())
+	}
+}
+
+func TestReleaseWakesReserve(t *testing.T) {
+
+	balance := &balance_struct{octas: 1000}
+	balance.reserve(600, 0)
+
+	reserved := make(chan bool)
+	go func() {
+		reserved <- balance.reserve(500, time.Second)
+	}()
+
+	select {
+	case <-reserved:
+		t.Fatal("reserved before release")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	balance.release(600)
+
+	select {
+	case ok := <-reserved:
+		if !ok || balance.available() != 500 {
+			t.Errorf("reserve %t, available %d, want 500", ok, balance.available())
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("release did not wake the waiting reserve")
+	}
+}
+
+func TestSetWakesReserve(t *testing.T) {
+
+	balance := &balance_struct{octas: 1000}
+	balance.reserve(600, 0)
+
+	reserved := make(chan bool)
+	go func() {
+		reserved <- balance.reserve(500, time.Second)
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// a refreshed balance also frees the waiting purchase
+	balance.set(1100)
+
+	select {
+	case ok := <-reserved:
+		if !ok {
+			t.Errorf("reserve failed after the balance went up")
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("set did not wake the waiting reserve")
+	}
+}
+
+func TestReleaseSettleClamp(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		octas    uint64
+		reserved uint64
+		settle   bool
+		amount   uint64
+		left     uint64
+		held     uint64
+	}{
+		{"release", 1000, 600, false, 600, 1000, 0},
+		{"release more than reserved", 1000, 600, false, 900, 1000, 0},
+		{"settle", 1000, 600, true, 600, 400, 0},
+		{"settle part", 1000, 600, true, 200, 800, 400},
+		// the reservation caps what settle takes out of the balance
+		{"settle more than reserved", 1000, 600, true, 900, 400, 0},
+		// a refresh already saw the spend, the balance never goes under zero
+		{"settle more than balance", 300, 600, true, 600, 0, 0},
+	}
+
+	for _, test := range tests {
+		balance := &balance_struct{octas: test.octas, reserved: test.reserved}
+		if test.settle {
+			balance.settle(test.amount)
+		} else {
+			balance.release(test.amount)
+		}
+
+		if balance.octas != test.left || balance.reserved != test.held {
+			t.Errorf("%s: octas %d reserved %d, want %d %d", test.name, balance.octas, balance.reserved, test.left, test.held)
+		}
+	}
+}
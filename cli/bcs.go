@@ -0,0 +1,825 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+/*
+--------------------BCS--------------------
+*/
+type bcs_serializer struct {
+	buf bytes.Buffer
+}
+
+func (s *bcs_serializer) data() []byte {
+	return s.buf.Bytes()
+}
+
+func (s *bcs_serializer) uleb128(v uint64) {
+	for v >= 0x80 {
+		s.buf.WriteByte(byte(v&0x7f) | 0x80)
+		v >>= 7
+	}
+	s.buf.WriteByte(byte(v))
+}
+
+func (s *bcs_serializer) bool(v bool) {
+	if v {
+		s.buf.WriteByte(1)
+	} else {
+		s.buf.WriteByte(0)
+	}
+}
+
+func (s *bcs_serializer) u8(v uint8) {
+	s.buf.WriteByte(v)
+}
+
+func (s *bcs_serializer) u16(v uint16) {
+	var b [2]byte
+	binary.LittleEndian.PutUint16(b[:], v)
+	s.buf.Write(b[:])
+}
+
+func (s *bcs_serializer) u32(v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	s.buf.Write(b[:])
+}
+
+func (s *bcs_serializer) u64(v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	s.buf.Write(b[:])
+}
+
+// u128 and u256 are written little endian with a fixed width
+func (s *bcs_serializer) big_uint(v *big.Int, size int) error {
+	if v.Sign() < 0 || v.BitLen() > size*8 {
+		return fmt.Errorf("bcs: value %s overflows u%d", v.String(), size*8)
+	}
+	b := make([]byte, size)
+	v.FillBytes(b)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	s.buf.Write(b)
+
+	return nil
+}
+
+func (s *bcs_serializer) fixed_bytes(v []byte) {
+	s.buf.Write(v)
+}
+
+func (s *bcs_serializer) bytes(v []byte) {
+	s.uleb128(uint64(len(v)))
+	s.buf.Write(v)
+}
+
+func (s *bcs_serializer) str(v string) {
+	s.bytes([]byte(v))
+}
+
+func (s *bcs_serializer) address(v [32]byte) {
+	s.buf.Write(v[:])
+}
+
+// parse_address accepts long and short (0x1) hex forms
+func parse_address(str string) ([32]byte, error) {
+	var address [32]byte
+
+	str = strings.TrimPrefix(strings.TrimSpace(str), "0x")
+	if len(str) == 0 || len(str) > 64 {
+		return address, fmt.Errorf("bcs: invalid address %q", str)
+	}
+	if len(str)%2 == 1 {
+		str = "0" + str
+	}
+
+	raw, err := hex.DecodeString(str)
+	if err != nil {
+		return address, fmt.Errorf("bcs: invalid address %q", str)
+	}
+	copy(address[32-len(raw):], raw)
+
+	return address, nil
+}
+
+/*
+----------Type tags----------
+*/
+type type_tag_struct struct {
+	kind      string // bool, u8, u16, u32, u64, u128, u256, address, signer, vector, struct
+	elem      *type_tag_struct
+	address   [32]byte
+	module    string
+	name      string
+	type_args []type_tag_struct
+}
+
+var type_tag_variant = map[string]uint64{
+	"bool":    0,
+	"u8":      1,
+	"u64":     2,
+	"u128":    3,
+	"address": 4,
+	"signer":  5,
+	"vector":  6,
+	"struct":  7,
+	"u16":     8,
+	"u32":     9,
+	"u256":    10,
+}
+
+func parse_type_tag(str string) (type_tag_struct, error) {
+	tag, rest, err := parse_type_tag_prefix(strings.TrimSpace(str))
+	if err != nil {
+		return tag, err
+	}
+	if strings.TrimSpace(rest) != "" {
+		return tag, fmt.Errorf("bcs: unexpected %q in type %q", rest, str)
+	}
+
+	return tag, nil
+}
+
+func parse_type_tag_prefix(str string) (type_tag_struct, string, error) {
+	var tag type_tag_struct
+
+	str = strings.TrimSpace(str)
+
+	// the identifier ends at the first generic bracket or separator
+	end := strings.IndexAny(str, "<>,")
+	if end == -1 {
+		end = len(str)
+	}
+	head := strings.TrimSpace(str[:end])
+	rest := str[end:]
+
+	switch head {
+	case "bool", "u8", "u16", "u32", "u64", "u128", "u256", "address", "signer":
+		tag.kind = head
+		return tag, rest, nil
+
+	case "vector":
+		if !strings.HasPrefix(rest, "<") {
+			return tag, rest, errors.New("bcs: vector without element type")
+		}
+		elem, rest, err := parse_type_tag_prefix(rest[1:])
+		if err != nil {
+			return tag, rest, err
+		}
+		rest = strings.TrimSpace(rest)
+		if !strings.HasPrefix(rest, ">") {
+			return tag, rest, errors.New("bcs: unclosed vector type")
+		}
+		tag.kind = "vector"
+		tag.elem = &elem
+		return tag, rest[1:], nil
+	}
+
+	parts := strings.Split(head, "::")
+	if len(parts) != 3 {
+		return tag, rest, fmt.Errorf("bcs: invalid type %q", head)
+	}
+
+	address, err := parse_address(parts[0])
+	if err != nil {
+		return tag, rest, err
+	}
+	tag.kind = "struct"
+	tag.address = address
+	tag.module = parts[1]
+	tag.name = parts[2]
+
+	if strings.HasPrefix(rest, "<") {
+		rest = rest[1:]
+		for {
+			arg, next, err := parse_type_tag_prefix(rest)
+			if err != nil {
+				return tag, next, err
+			}
+			tag.type_args = append(tag.type_args, arg)
+
+			next = strings.TrimSpace(next)
+			if strings.HasPrefix(next, ",") {
+				rest = next[1:]
+				continue
+			}
+			if strings.HasPrefix(next, ">") {
+				rest = next[1:]
+				break
+			}
+			return tag, next, fmt.Errorf("bcs: unclosed generic in %q", head)
+		}
+	}
+
+	return tag, rest, nil
+}
+
+func (tag type_tag_struct) is_struct(address string, module string, name string) bool {
+	want, _ := parse_address(address)
+	return tag.kind == "struct" && tag.address == want && tag.module == module && tag.name == name
+}
+
+func (tag type_tag_struct) serialize(s *bcs_serializer) {
+	s.uleb128(type_tag_variant[tag.kind])
+
+	switch tag.kind {
+	case "vector":
+		tag.elem.serialize(s)
+	case "struct":
+		s.address(tag.address)
+		s.str(tag.module)
+		s.str(tag.name)
+		s.uleb128(uint64(len(tag.type_args)))
+		for _, arg := range tag.type_args {
+			arg.serialize(s)
+		}
+	}
+}
+
+/*
+----------Move values----------
+*/
+func encode_move_value(s *bcs_serializer, tag type_tag_struct, value interface{}) error {
+
+	switch tag.kind {
+	case "bool":
+		switch v := value.(type) {
+		case bool:
+			s.bool(v)
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("bcs: invalid bool %q", v)
+			}
+			s.bool(b)
+		default:
+			return fmt.Errorf("bcs: invalid bool %v", value)
+		}
+
+	case "u8", "u16", "u32", "u64", "u128", "u256":
+		n, err := move_integer(value)
+		if err != nil {
+			return err
+		}
+		switch tag.kind {
+		case "u128":
+			return s.big_uint(n, 16)
+		case "u256":
+			return s.big_uint(n, 32)
+		}
+		bits, _ := strconv.Atoi(tag.kind[1:])
+		if n.Sign() < 0 || n.BitLen() > bits {
+			return fmt.Errorf("bcs: value %s overflows %s", n.String(), tag.kind)
+		}
+		switch tag.kind {
+		case "u8":
+			s.u8(uint8(n.Uint64()))
+		case "u16":
+			s.u16(uint16(n.Uint64()))
+		case "u32":
+			s.u32(uint32(n.Uint64()))
+		case "u64":
+			s.u64(n.Uint64())
+		}
+
+	case "address":
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("bcs: invalid address %v", value)
+		}
+		address, err := parse_address(str)
+		if err != nil {
+			return err
+		}
+		s.address(address)
+
+	case "vector":
+		// vector<u8> may be given as a hex string
+		if str, ok := value.(string); ok && tag.elem.kind == "u8" {
+			raw, err := hex.DecodeString(strings.TrimPrefix(str, "0x"))
+			if err != nil {
+				return fmt.Errorf("bcs: invalid hex bytes %q", str)
+			}
+			s.bytes(raw)
+			return nil
+		}
+
+		items, err := move_vector(value)
+		if err != nil {
+			return err
+		}
+		s.uleb128(uint64(len(items)))
+		for _, item := range items {
+			if err := encode_move_value(s, *tag.elem, item); err != nil {
+				return err
+			}
+		}
+
+	case "struct":
+		switch {
+		case tag.is_struct("0x1", "string", "String"):
+			str, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("bcs: invalid string %v", value)
+			}
+			s.str(str)
+
+		case tag.is_struct("0x1", "object", "Object"):
+			return encode_move_value(s, type_tag_struct{kind: "address"}, value)
+
+		case tag.is_struct("0x1", "option", "Option"):
+			// option is encoded as a vector of zero or one element
+			if len(tag.type_args) != 1 {
+				return fmt.Errorf("bcs: 0x1::option::Option needs one type argument, got %d", len(tag.type_args))
+			}
+			// only {"vec": []} or {"vec": [value]}, the json form of the node, a bare array is ambiguous for Option<vector<T>>
+			object, ok := value.(map[string]interface{})
+			if !ok || len(object) != 1 {
+				return fmt.Errorf(`bcs: invalid option %v, use {"vec": []} or {"vec": [value]}`, value)
+			}
+			items, err := move_vector(object["vec"])
+			if err != nil || len(items) > 1 {
+				return fmt.Errorf(`bcs: invalid option %v, use {"vec": []} or {"vec": [value]}`, value)
+			}
+			s.uleb128(uint64(len(items)))
+			for _, item := range items {
+				if err := encode_move_value(s, tag.type_args[0], item); err != nil {
+					return err
+				}
+			}
+
+		default:
+			return fmt.Errorf("bcs: unsupported argument type %s::%s", tag.module, tag.name)
+		}
+
+	default:
+		return fmt.Errorf("bcs: unsupported argument type %s", tag.kind)
+	}
+
+	return nil
+}
+
+func move_integer(value interface{}) (*big.Int, error) {
+	n := new(big.Int)
+
+	switch v := value.(type) {
+	case string:
+		if _, ok := n.SetString(v, 10); !ok {
+			return nil, fmt.Errorf("bcs: invalid integer %q", v)
+		}
+	case json.Number:
+		if _, ok := n.SetString(v.String(), 10); !ok {
+			return nil, fmt.Errorf("bcs: invalid integer %s", v)
+		}
+	case float64:
+		// above 2^53 the json number was already rounded
+		if v != float64(uint64(v)) || v > 1<<53 {
+			return nil, fmt.Errorf("bcs: invalid integer %v, quote large integers", v)
+		}
+		n.SetUint64(uint64(v))
+	case int:
+		n.SetInt64(int64(v))
+	case uint64:
+		n.SetUint64(v)
+	default:
+		return nil, fmt.Errorf("bcs: invalid integer %v", value)
+	}
+
+	return n, nil
+}
+
+// unmarshal_arguments decodes json numbers as json.Number, float64 rounds u64 and larger integers
+func unmarshal_arguments(data []byte, v interface{}) error {
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return errors.New("invalid data after top-level value")
+	}
+
+	return nil
+}
+
+func move_vector(value interface{}) ([]interface{}, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		return v, nil
+	case []string:
+		items := make([]interface{}, len(v))
+		for i := range v {
+			items[i] = v[i]
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("bcs: invalid vector %v", value)
+	}
+}
+
+/*
+----------Transactions----------
+*/
+// transaction_payload_struct is the TransactionPayload enum, entry function or script
+type transaction_payload_struct interface {
+	variant() uint64
+	serialize(s *bcs_serializer)
+}
+
+type entry_function_struct struct {
+	module_address [32]byte
+	module_name    string
+	function       string
+	type_args      []type_tag_struct
+	args           [][]byte
+}
+
+type raw_transaction_struct struct {
+	sender                    [32]byte
+	sequence_number           uint64
+	payload                   transaction_payload_struct
+	max_gas_amount            uint64
+	gas_unit_price            uint64
+	expiration_timestamp_secs uint64
+	chain_id                  uint8
+}
+
+// entry_function converts the json payload to its bcs form, arguments are typed by ArgumentTypes
+func (payload payload_struct) entry_function() (entry_function_struct, error) {
+	var entry_function entry_function_struct
+
+	parts := strings.Split(payload.Function, "::")
+	if len(parts) != 3 {
+		return entry_function, fmt.Errorf("bcs: invalid function %q", payload.Function)
+	}
+
+	address, err := parse_address(parts[0])
+	if err != nil {
+		return entry_function, err
+	}
+	entry_function.module_address = address
+	entry_function.module_name = parts[1]
+	entry_function.function = parts[2]
+
+	for _, type_argument := range payload.TypeArguments {
+		tag, err := parse_type_tag(type_argument)
+		if err != nil {
+			return entry_function, err
+		}
+		entry_function.type_args = append(entry_function.type_args, tag)
+	}
+
+	arguments, err := move_vector(payload.Arguments)
+	if err != nil {
+		// [][]string and other nested literals go through the generic path
+		arguments, err = nested_arguments(payload.Arguments)
+		if err != nil {
+			return entry_function, err
+		}
+	}
+	if len(arguments) != len(payload.ArgumentTypes) {
+		return entry_function, fmt.Errorf("bcs: %s expects %d arguments, got %d", payload.Function, len(payload.ArgumentTypes), len(arguments))
+	}
+
+	for i, argument := range arguments {
+		tag, err := parse_type_tag(payload.ArgumentTypes[i])
+		if err != nil {
+			return entry_function, err
+		}
+
+		var s bcs_serializer
+		if err := encode_move_value(&s, tag, argument); err != nil {
+			return entry_function, fmt.Errorf("argument %d: %w", i, err)
+		}
+		entry_function.args = append(entry_function.args, s.data())
+	}
+
+	return entry_function, nil
+}
+
+func nested_arguments(value interface{}) ([]interface{}, error) {
+	switch v := value.(type) {
+	case nil:
+		return []interface{}{}, nil
+	case [][]string:
+		items := make([]interface{}, len(v))
+		for i := range v {
+			items[i] = v[i]
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("bcs: unsupported arguments %T", value)
+	}
+}
+
+func (entry_function entry_function_struct) variant() uint64 {
+	return 2
+}
+
+func (entry_function entry_function_struct) serialize(s *bcs_serializer) {
+	s.address(entry_function.module_address)
+	s.str(entry_function.module_name)
+	s.str(entry_function.function)
+
+	s.uleb128(uint64(len(entry_function.type_args)))
+	for _, tag := range entry_function.type_args {
+		tag.serialize(s)
+	}
+
+	s.uleb128(uint64(len(entry_function.args)))
+	for _, arg := range entry_function.args {
+		s.bytes(arg)
+	}
+}
+
+func (txn raw_transaction_struct) serialize() []byte {
+	var s bcs_serializer
+
+	s.address(txn.sender)
+	s.u64(txn.sequence_number)
+
+	s.uleb128(txn.payload.variant())
+	txn.payload.serialize(&s)
+
+	s.u64(txn.max_gas_amount)
+	s.u64(txn.gas_unit_price)
+	s.u64(txn.expiration_timestamp_secs)
+	s.u8(txn.chain_id)
+
+	return s.data()
+}
+
+// signing_message is sha3("APTOS::RawTransaction") || bcs(raw transaction)
+func (txn raw_transaction_struct) signing_message() []byte {
+	prefix := sha3.Sum256([]byte("APTOS::RawTransaction"))
+	return append(prefix[:], txn.serialize()...)
+}
+
+/*
+----------Scripts----------
+*/
+type script_struct struct {
+	code      []byte
+	type_args []type_tag_struct
+	args      [][]byte // TransactionArgument, variant included
+}
+
+// TransactionArgument variants, Serialized carries any other move value as bcs bytes
+var script_argument_variant = map[string]uint64{
+	"u8":         0,
+	"u64":        1,
+	"u128":       2,
+	"address":    3,
+	"vector<u8>": 4,
+	"bool":       5,
+	"u16":        6,
+	"u32":        7,
+	"u256":       8,
+}
+
+const script_argument_serialized = 9
+
+func (script script_struct) variant() uint64 {
+	return 0
+}
+
+func (script script_struct) serialize(s *bcs_serializer) {
+	s.bytes(script.code)
+
+	s.uleb128(uint64(len(script.type_args)))
+	for _, tag := range script.type_args {
+		tag.serialize(s)
+	}
+
+	s.uleb128(uint64(len(script.args)))
+	for _, arg := range script.args {
+		s.fixed_bytes(arg)
+	}
+}
+
+// encode_script_argument writes the TransactionArgument of value
+func encode_script_argument(s *bcs_serializer, tag type_tag_struct, value interface{}) error {
+
+	var value_s bcs_serializer
+	if err := encode_move_value(&value_s, tag, value); err != nil {
+		return err
+	}
+
+	kind := tag.kind
+	if kind == "vector" && tag.elem.kind == "u8" {
+		kind = "vector<u8>"
+	}
+
+	variant, ok := script_argument_variant[kind]
+	if !ok {
+		s.uleb128(script_argument_serialized)
+		s.bytes(value_s.data())
+		return nil
+	}
+
+	s.uleb128(variant)
+	s.fixed_bytes(value_s.data())
+
+	return nil
+}
+
+// script converts a script payload, the bytecode is hex in Code
+func (payload payload_struct) script() (script_struct, error) {
+	var script script_struct
+
+	if payload.Code == nil {
+		return script, errors.New("bcs: script payload without code")
+	}
+
+	code, err := hex.DecodeString(strings.TrimPrefix(payload.Code.Bytecode, "0x"))
+	if err != nil || len(code) == 0 {
+		return script, errors.New("bcs: invalid script bytecode")
+	}
+	script.code = code
+
+	for _, type_argument := range payload.TypeArguments {
+		tag, err := parse_type_tag(type_argument)
+		if err != nil {
+			return script, err
+		}
+		script.type_args = append(script.type_args, tag)
+	}
+
+	arguments, err := move_vector(payload.Arguments)
+	if err != nil {
+		arguments, err = nested_arguments(payload.Arguments)
+		if err != nil {
+			return script, err
+		}
+	}
+	if len(arguments) != len(payload.ArgumentTypes) {
+		return script, fmt.Errorf("bcs: script expects %d arguments, got %d", len(payload.ArgumentTypes), len(arguments))
+	}
+
+	for i, argument := range arguments {
+		tag, err := parse_type_tag(payload.ArgumentTypes[i])
+		if err != nil {
+			return script, err
+		}
+
+		var s bcs_serializer
+		if err := encode_script_argument(&s, tag, argument); err != nil {
+			return script, fmt.Errorf("argument %d: %w", i, err)
+		}
+		script.args = append(script.args, s.data())
+	}
+
+	return script, nil
+}
+
+// transaction_payload picks the bcs payload from the json payload type
+func (payload payload_struct) transaction_payload() (transaction_payload_struct, error) {
+	if payload.Type == "script_payload" {
+		return payload.script()
+	}
+
+	return payload.entry_function()
+}
+
+/*
+----------Authenticators----------
+*/
+type account_authenticator_struct struct {
+	variant    uint64 // Ed25519 or MultiEd25519
+	public_key []byte // MultiEd25519: public keys || threshold
+	signature  []byte // MultiEd25519: signatures || bitmap
+}
+
+const (
+	authenticator_ed25519       = 0
+	authenticator_multi_ed25519 = 1
+)
+
+type fee_payer_struct struct {
+	address       [32]byte
+	authenticator account_authenticator_struct
+}
+
+// AccountAuthenticator::Ed25519 and MultiEd25519 share variant and layout with the TransactionAuthenticator ones
+func (authenticator account_authenticator_struct) serialize(s *bcs_serializer) {
+	s.uleb128(authenticator.variant)
+	s.bytes(authenticator.public_key)
+	s.bytes(authenticator.signature)
+}
+
+func (authenticator account_authenticator_struct) json() map[string]interface{} {
+	if authenticator.variant == authenticator_multi_ed25519 {
+		return authenticator.multi_ed25519_json()
+	}
+
+	return map[string]interface{}{
+		"type":       "ed25519_signature",
+		"public_key": fmt.Sprintf("0x%x", authenticator.public_key),
+		"signature":  fmt.Sprintf("0x%x", authenticator.signature),
+	}
+}
+
+// multi_ed25519_json splits the multi key and signature bytes back into their parts
+func (authenticator account_authenticator_struct) multi_ed25519_json() map[string]interface{} {
+
+	keys := authenticator.public_key[:len(authenticator.public_key)-1]
+	threshold := authenticator.public_key[len(authenticator.public_key)-1]
+
+	var public_keys []string
+	for i := 0; i+32 <= len(keys); i += 32 {
+		public_keys = append(public_keys, fmt.Sprintf("0x%x", keys[i:i+32]))
+	}
+
+	signatures_bytes := authenticator.signature[:len(authenticator.signature)-4]
+	bitmap := authenticator.signature[len(authenticator.signature)-4:]
+
+	var signatures []string
+	for i := 0; i+64 <= len(signatures_bytes); i += 64 {
+		signatures = append(signatures, fmt.Sprintf("0x%x", signatures_bytes[i:i+64]))
+	}
+
+	return map[string]interface{}{
+		"type":        "multi_ed25519_signature",
+		"public_keys": public_keys,
+		"signatures":  signatures,
+		"threshold":   threshold,
+		"bitmap":      fmt.Sprintf("0x%x", bitmap),
+	}
+}
+
+// fee_payer_signing_message signs RawTransactionWithData::MultiAgentWithFeePayer without secondary signers
+func (txn raw_transaction_struct) fee_payer_signing_message(fee_payer [32]byte) []byte {
+	var s bcs_serializer
+
+	prefix := sha3.Sum256([]byte("APTOS::RawTransactionWithData"))
+	s.fixed_bytes(prefix[:])
+
+	s.uleb128(1)
+	s.fixed_bytes(txn.serialize())
+	s.uleb128(0)
+	s.address(fee_payer)
+
+	return s.data()
+}
+
+func (txn raw_transaction_struct) signed_transaction(sender account_authenticator_struct, fee_payer *fee_payer_struct) []byte {
+	var s bcs_serializer
+
+	s.fixed_bytes(txn.serialize())
+
+	if fee_payer == nil {
+		sender.serialize(&s)
+		return s.data()
+	}
+
+	// TransactionAuthenticator::FeePayer
+	s.uleb128(3)
+	sender.serialize(&s)
+	s.uleb128(0)
+	s.uleb128(0)
+	s.address(fee_payer.address)
+	fee_payer.authenticator.serialize(&s)
+
+	return s.data()
+}
+
+func signature_json(sender account_authenticator_struct, fee_payer *fee_payer_struct) map[string]interface{} {
+	if fee_payer == nil {
+		return sender.json()
+	}
+
+	return map[string]interface{}{
+		"type":                       "fee_payer_signature",
+		"sender":                     sender.json(),
+		"secondary_signer_addresses": []string{},
+		"secondary_signers":          []interface{}{},
+		"fee_payer_address":          fmt.Sprintf("0x%x", fee_payer.address),
+		"fee_payer_signer":           fee_payer.authenticator.json(),
+	}
+}
+
+// transaction_hash is sha3(sha3("APTOS::Transaction") || Transaction::UserTransaction || signed transaction)
+func transaction_hash(signed_transaction []byte) string {
+	prefix := sha3.Sum256([]byte("APTOS::Transaction"))
+
+	data := append(prefix[:], 0)
+	data = append(data, signed_transaction...)
+
+	return fmt.Sprintf("0x%x", sha3.Sum256(data))
+}
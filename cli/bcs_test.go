@@ -0,0 +1,356 @@
+package main
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// vectors are assembled byte by byte from the aptos bcs layout, signatures and
+// hashes computed with an independent ed25519 and sha3-256 implementation
+
+const (
+	test_seed_1 = "0x0101010101010101010101010101010101010101010101010101010101010101"
+	test_seed_2 = "0x0202020202020202020202020202020202020202020202020202020202020202"
+	test_seed_3 = "0x0303030303030303030303030303030303030303030303030303030303030303"
+
+	test_sender    = "0x7df415e5b21bdaa8b2946e8f1f4278b39904e51a69627494cd3e6f2996732fbd"
+	test_fee_payer = "0x7d9947d5ce9efdd02bb88c44cf2f941c829ed5ac483090a6ba22c12db9251c41"
+
+	test_raw_entry    = "7df415e5b21bdaa8b2946e8f1f4278b39904e51a69627494cd3e6f2996732fbd070000000000000002000000000000000000000000000000000000000000000000000000000000000104636f696e087472616e73666572010700000000000000000000000000000000000000000000000000000000000000010a6170746f735f636f696e094170746f73436f696e00022000000000000000000000000000000000000000000000000000000000000000dd08e803000000000000d007000000000000640000000000000000f153650000000001"
+	test_signed_entry = "7df415e5b21bdaa8b2946e8f1f4278b39904e51a69627494cd3e6f2996732fbd070000000000000002000000000000000000000000000000000000000000000000000000000000000104636f696e087472616e73666572010700000000000000000000000000000000000000000000000000000000000000010a6170746f735f636f696e094170746f73436f696e00022000000000000000000000000000000000000000000000000000000000000000dd08e803000000000000d007000000000000640000000000000000f15365000000000100208a88e3dd7409f195fd52db2d3cba5d72ca6709bf1d94121bf3748801b40f6f5c405125ee641319b820f9e804e697c3f408958a51b6efc7c7b31357b4cb0e5c723480e4e0e6d44a57c65783485655096fc3572a3c251c3b6d4f0a5726cbcb3cbb05"
+	test_hash_entry   = "0x5fc4be0342c3e2c1ec64d98b5f17e718ca3443a8bb74862facccc5e2e62563f2"
+
+	test_raw_script  = "7df415e5b21bdaa8b2946e8f1f4278b39904e51a69627494cd3e6f2996732fbd0700000000000000000ca11ceb0b0600000001020304010700000000000000000000000000000000000000000000000000000000000000010a6170746f735f636f696e094170746f73436f696e000a00ff06ffff07ffffffff01e80300000000000002ffffffffffffffffffffffffffffffff0801000000000000000000000000000000000000000000000000000000000000800300000000000000000000000000000000000000000000000000000000000000dd0402cafe050109410200000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000002d007000000000000640000000000000000f153650000000001"
+	test_hash_script = "0x320e68662b6deed68dda7c0b5b3b7e67ca494d599f929c7611860eb0f4c741dd"
+
+	test_multi_address = "0xe103d0e6e67b017524bebf94ae151df6a70c6f354178a88a9a3865bcafabfdb4"
+	test_signed_multi  = "e103d0e6e67b017524bebf94ae151df6a70c6f354178a88a9a3865bcafabfdb4070000000000000002000000000000000000000000000000000000000000000000000000000000000104636f696e087472616e73666572010700000000000000000000000000000000000000000000000000000000000000010a6170746f735f636f696e094170746f73436f696e00022000000000000000000000000000000000000000000000000000000000000000dd08e803000000000000d007000000000000640000000000000000f15365000000000101618a88e3dd7409f195fd52db2d3cba5d72ca6709bf1d94121bf3748801b40f6f5c8139770ea87d175f56a35466c34c7ecccb8d8a91b4ee37a25df60f5b8fc9b394ed4928c628d1c2c6eae90338905995612959273a5c63f93636c14614ac8737d1028401bc2b0c1885f3c145a26fe5ec9498be5cad85bcf2d73a0e45f1ba29ec04b8cef401da91482ab724603c0556c8d701b1a0b1e5fa355cb46591c8dcdf5a8c5ea20369362a07f7af94b06f8bc569fc5574b96c6d6acc4db38594f63ec3133be422e7c5ff88c0d66119b48036939dc3032ed4fad59cb421cfd98b72320436a9464f04a0000000"
+	test_hash_multi    = "0x3ac9e04ba0dd97ba9a1ce785304c4602fa66865d8a66538abc1475a622f5feb4"
+
+	test_fee_message = "5efa3c4f02f83a0f4b2d69fc95c607cc02825cc4e7be536ef0992df050d9e67c017df415e5b21bdaa8b2946e8f1f4278b39904e51a69627494cd3e6f2996732fbd070000000000000002000000000000000000000000000000000000000000000000000000000000000104636f696e087472616e73666572010700000000000000000000000000000000000000000000000000000000000000010a6170746f735f636f696e094170746f73436f696e00022000000000000000000000000000000000000000000000000000000000000000dd08e803000000000000d007000000000000640000000000000000f153650000000001007d9947d5ce9efdd02bb88c44cf2f941c829ed5ac483090a6ba22c12db9251c41"
+	test_signed_fee  = "7df415e5b21bdaa8b2946e8f1f4278b39904e51a69627494cd3e6f2996732fbd070000000000000002000000000000000000000000000000000000000000000000000000000000000104636f696e087472616e73666572010700000000000000000000000000000000000000000000000000000000000000010a6170746f735f636f696e094170746f73436f696e00022000000000000000000000000000000000000000000000000000000000000000dd08e803000000000000d007000000000000640000000000000000f1536500000000010300208a88e3dd7409f195fd52db2d3cba5d72ca6709bf1d94121bf3748801b40f6f5c4036474efa52a1ca7a7eeda85e3b820410bef0a662f0db30b8e90a9d082be8e5482b1e8a12e2874ecebbea9018893c30a269aeb92d97c67bede1d72c384ec35a0400007d9947d5ce9efdd02bb88c44cf2f941c829ed5ac483090a6ba22c12db9251c4100208139770ea87d175f56a35466c34c7ecccb8d8a91b4ee37a25df60f5b8fc9b39440572d90d241a68ccc296f9872102eb95be6317905a4c4cb2b445987c7db8f02ad1589f8cd890de74a13cf64e7b90a8b31dc4edcd1799fa17f8718e7bcb7c4700a"
+	test_hash_fee    = "0x3e2952603244e77b0f2063bb79aa7f75324529d621be715a625295b391aef3dd"
+)
+
+func test_wallet(t *testing.T, seed string) wallet_struct {
+	t.Helper()
+
+	wallet, err := new_wallet(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return wallet
+}
+
+// test_raw_transaction is sequence 7, max gas 2000, gas price 100, expiration 1700000000 on chain 1
+func test_raw_transaction(t *testing.T, sender [32]byte, payload payload_struct) raw_transaction_struct {
+	t.Helper()
+
+	transaction_payload, err := payload.transaction_payload()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return raw_transaction_struct{
+		sender:                    sender,
+		sequence_number:           7,
+		payload:                   transaction_payload,
+		max_gas_amount:            2000,
+		gas_unit_price:            100,
+		expiration_timestamp_secs: 1700000000,
+		chain_id:                  1,
+	}
+}
+
+func test_transfer_payload() payload_struct {
+	return payload_struct{
+		Type:          "entry_function_payload",
+		Function:      "0x1::coin::transfer",
+		TypeArguments: []string{"0x1::aptos_coin::AptosCoin"},
+		Arguments:     []interface{}{"0xdd", "1000"},
+		ArgumentTypes: []string{"address", "u64"},
+	}
+}
+
+func expect_hex(t *testing.T, name string, got []byte, want string) {
+	t.Helper()
+
+	if hex.EncodeToString(got) != want {
+		t.Errorf("%s\n got  %x\n want %s", name, got, want)
+	}
+}
+
+func TestBcsEntryFunction(t *testing.T) {
+
+	wallet := test_wallet(t, test_seed_1)
+	if wallet.address_str != test_sender {
+		t.Fatalf("address %s, want %s", wallet.address_str, test_sender)
+	}
+
+	raw := test_raw_transaction(t, wallet.address, test_transfer_payload())
+	expect_hex(t, "raw transaction", raw.serialize(), test_raw_entry)
+	expect_hex(t, "signing message", raw.signing_message(), "b5e97db07fa0bd0e5598aa3643a9bc6f6693bddc1a9fec9e674a461eaa00b193"+test_raw_entry)
+
+	sender, err := wallet.sign(raw.signing_message())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed := raw.signed_transaction(sender, nil)
+	expect_hex(t, "signed transaction", signed, test_signed_entry)
+
+	if hash := transaction_hash(signed); hash != test_hash_entry {
+		t.Errorf("hash %s, want %s", hash, test_hash_entry)
+	}
+}
+
+func TestBcsScript(t *testing.T) {
+
+	wallet := test_wallet(t, test_seed_1)
+
+	payload := payload_struct{
+		Type:          "script_payload",
+		Code:          &code_struct{Bytecode: "0xa11ceb0b0600000001020304"},
+		TypeArguments: []string{"0x1::aptos_coin::AptosCoin"},
+		Arguments: []interface{}{
+			"255", "65535", "4294967295", "1000",
+			"340282366920938463463374607431768211455",
+			"57896044618658097711785492504343953926634992332820282019728792003956564819969",
+			"0xdd", "0xcafe", true, []interface{}{"0x1", "0x2"},
+		},
+		ArgumentTypes: []string{"u8", "u16", "u32", "u64", "u128", "u256", "address", "vector<u8>", "bool", "vector<address>"},
+	}
+
+	raw := test_raw_transaction(t, wallet.address, payload)
+	expect_hex(t, "raw transaction", raw.serialize(), test_raw_script)
+
+	sender, err := wallet.sign(raw.signing_message())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hash := transaction_hash(raw.signed_transaction(sender, nil)); hash != test_hash_script {
+		t.Errorf("hash %s, want %s", hash, test_hash_script)
+	}
+}
+
+func TestBcsMultiEd25519(t *testing.T) {
+
+	var public_keys []string
+	for _, seed := range []string{test_seed_1, test_seed_2, test_seed_3} {
+		public_keys = append(public_keys, test_wallet(t, seed).publicKeyStr)
+	}
+
+	// keys 0 and 2 sign, bitmap 0xa0000000
+	wallet, err := new_multi_wallet([]string{test_seed_1, test_seed_3}, public_keys, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wallet.address_str != test_multi_address {
+		t.Fatalf("address %s, want %s", wallet.address_str, test_multi_address)
+	}
+
+	raw := test_raw_transaction(t, wallet.address, test_transfer_payload())
+
+	sender, err := wallet.sign(raw.signing_message())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed := raw.signed_transaction(sender, nil)
+	expect_hex(t, "signed transaction", signed, test_signed_multi)
+
+	if hash := transaction_hash(signed); hash != test_hash_multi {
+		t.Errorf("hash %s, want %s", hash, test_hash_multi)
+	}
+}
+
+func TestBcsFeePayer(t *testing.T) {
+
+	wallet := test_wallet(t, test_seed_1)
+	gas_wallet := test_wallet(t, test_seed_2)
+	if gas_wallet.address_str != test_fee_payer {
+		t.Fatalf("address %s, want %s", gas_wallet.address_str, test_fee_payer)
+	}
+
+	raw := test_raw_transaction(t, wallet.address, test_transfer_payload())
+
+	message := raw.fee_payer_signing_message(gas_wallet.address)
+	expect_hex(t, "fee payer signing message", message, test_fee_message)
+
+	sender, err := wallet.sign(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fee_payer, err := gas_wallet.sign(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed := raw.signed_transaction(sender, &fee_payer_struct{address: gas_wallet.address, authenticator: fee_payer})
+	expect_hex(t, "signed transaction", signed, test_signed_fee)
+
+	if hash := transaction_hash(signed); hash != test_hash_fee {
+		t.Errorf("hash %s, want %s", hash, test_hash_fee)
+	}
+}
+
+func TestBcsTypeTag(t *testing.T) {
+
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{"u8", "01"},
+		{"u256", "0a"},
+		{"vector<address>", "0604"},
+		{"vector<0x1::option::Option<u64>>", "06070000000000000000000000000000000000000000000000000000000000000001066f7074696f6e064f7074696f6e0102"},
+		{"0x1::aptos_coin::AptosCoin", "070000000000000000000000000000000000000000000000000000000000000001" + "0a6170746f735f636f696e094170746f73436f696e00"},
+	}
+
+	for _, test := range tests {
+		tag, err := parse_type_tag(test.tag)
+		if err != nil {
+			t.Fatalf("%s: %s", test.tag, err)
+		}
+
+		var s bcs_serializer
+		tag.serialize(&s)
+		expect_hex(t, test.tag, s.data(), test.want)
+	}
+
+	for _, invalid := range []string{"vector", "vector<u8", "0x1::coin", "0x1::option::Option<u8"} {
+		if _, err := parse_type_tag(invalid); err == nil {
+			t.Errorf("%s: expected error", invalid)
+		}
+	}
+}
+
+func TestBcsBigUint(t *testing.T) {
+
+	max_u128, _ := new(big.Int).SetString("340282366920938463463374607431768211455", 10)
+	u256 := new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(1))
+
+	tests := []struct {
+		value *big.Int
+		size  int
+		want  string
+	}{
+		{big.NewInt(1), 16, "01" + strings.Repeat("00", 15)},
+		{max_u128, 16, "ffffffffffffffffffffffffffffffff"},
+		{u256, 32, "0100000000000000000000000000000000000000000000000000000000000080"},
+	}
+
+	for _, test := range tests {
+		var s bcs_serializer
+		if err := s.big_uint(test.value, test.size); err != nil {
+			t.Fatal(err)
+		}
+		expect_hex(t, test.value.String(), s.data(), test.want)
+	}
+
+	var s bcs_serializer
+	if err := s.big_uint(new(big.Int).Add(max_u128, big.NewInt(1)), 16); err == nil {
+		t.Error("2^128 as u128: expected overflow")
+	}
+	if err := s.big_uint(big.NewInt(-1), 32); err == nil {
+		t.Error("-1 as u256: expected overflow")
+	}
+	if len(s.data()) != 0 {
+		t.Errorf("overflow wrote %x", s.data())
+	}
+}
+
+func TestBcsJsonIntegers(t *testing.T) {
+
+	// 123456789012345679 is not a float64, decoded as one it becomes 123456789012345680
+	var arguments []interface{}
+	if err := unmarshal_arguments([]byte(`[123456789012345679, "18446744073709551615", 7]`), &arguments); err != nil {
+		t.Fatal(err)
+	}
+
+	tag, _ := parse_type_tag("u64")
+	for i, want := range []string{"4ff330a64b9bb601", "ffffffffffffffff", "0700000000000000"} {
+		var s bcs_serializer
+		if err := encode_move_value(&s, tag, arguments[i]); err != nil {
+			t.Fatal(err)
+		}
+		expect_hex(t, "u64 argument", s.data(), want)
+	}
+
+	for _, invalid := range []interface{}{123456789012345679.0, 1.5, -1.0} {
+		var s bcs_serializer
+		if err := encode_move_value(&s, tag, invalid); err == nil {
+			t.Errorf("%v: expected error", invalid)
+		}
+	}
+
+	if err := unmarshal_arguments([]byte(`["0x1"] ["0x2"]`), &arguments); err == nil {
+		t.Error("trailing data: expected error")
+	}
+}
+
+func TestBcsOption(t *testing.T) {
+
+	// an option is {"vec": []} or {"vec": [value]}, the value of Option<vector<T>> is never guessed
+	tests := []struct {
+		tag   string
+		value string
+		want  string
+	}{
+		{"0x1::option::Option<u8>", `{"vec": []}`, "00"},
+		{"0x1::option::Option<u8>", `{"vec": ["7"]}`, "0107"},
+		{"0x1::option::Option<vector<u8>>", `{"vec": []}`, "00"},
+		{"0x1::option::Option<vector<u8>>", `{"vec": [[]]}`, "0100"},
+		{"0x1::option::Option<vector<u8>>", `{"vec": ["0x0102"]}`, "01020102"},
+		{"0x1::option::Option<vector<u64>>", `{"vec": [["1", 2]]}`, "010201000000000000000200000000000000"},
+		{"0x1::option::Option<0x1::option::Option<u8>>", `{"vec": [{"vec": []}]}`, "0100"},
+		{"vector<0x1::option::Option<address>>", `[{"vec": ["0x1"]}, {"vec": []}]`, "0201" + strings.Repeat("00", 31) + "01" + "00"},
+	}
+
+	for _, test := range tests {
+		tag, err := parse_type_tag(test.tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var value interface{}
+		if err := unmarshal_arguments([]byte(test.value), &value); err != nil {
+			t.Fatal(err)
+		}
+
+		var s bcs_serializer
+		if err := encode_move_value(&s, tag, value); err != nil {
+			t.Fatalf("%s %s: %s", test.tag, test.value, err)
+		}
+		expect_hex(t, test.tag+" "+test.value, s.data(), test.want)
+	}
+
+	// bare values, bare arrays and null are refused
+	tag, _ := parse_type_tag("0x1::option::Option<vector<u8>>")
+	for _, invalid := range []string{`null`, `[]`, `["0x01"]`, `"0x01"`, `{"vec": ["0x01", "0x02"]}`, `{"vec": "0x01"}`, `{"some": "0x01"}`, `{"vec": [], "some": 1}`} {
+		var value interface{}
+		unmarshal_arguments([]byte(invalid), &value)
+
+		var s bcs_serializer
+		if err := encode_move_value(&s, tag, value); err == nil {
+			t.Errorf("%s: expected error", invalid)
+		}
+	}
+
+	// an option without its type argument is refused instead of panicking
+	for _, invalid := range []string{"0x1::option::Option", "0x1::option::Option<u8, u64>"} {
+		tag, err := parse_type_tag(invalid)
+		if err != nil {
+			continue
+		}
+
+		var s bcs_serializer
+		if err := encode_move_value(&s, tag, "7"); err == nil {
+			t.Errorf("%s: expected error", invalid)
+		}
+	}
+}
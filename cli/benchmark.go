@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gookit/color"
+	"github.com/paulrademacher/climenu"
+)
+
+/*
+--------------------Benchmark--------------------
+*/
+// reject posts a zero signed transaction, it measures the signature rejection and not a mempool submit
+var benchmark_steps = []string{"ledger", "account", "simulate", "reject"}
+
+type benchmark_struct struct {
+	node    string
+	step    string
+	samples []time.Duration
+	errors  int
+}
+
+var benchmark_client = &http.Client{Timeout: 10 * time.Second}
+
+func benchmark(Config *config_struct) {
+
+	Clear(4, "action > benchmark nodes", "info")
+
+	var rounds int
+	for {
+		input := climenu.GetText("Rounds per node", "eg: 20")
+		Clear(1, nil, nil)
+		if n, err := strconv.Atoi(input); err == nil && n > 0 {
+			rounds = n
+			break
+		}
+	}
+
+	urls := Config.network().Nodes
+	fmt.Printf("%s %d nodes, %d rounds\n", color.Magenta.Text("Benchmark "), len(urls), rounds)
+
+	print_benchmark(benchmark_nodes(urls, Config.wallet.address_str, Config.wallet.publicKeyStr, rounds))
+
+	color.Grayf("Press enter for back")
+	fmt.Scanln()
+}
+
+// benchmark_nodes measures every step against every node, one node at a time
+func benchmark_nodes(urls []string, address string, public_key string, rounds int) []benchmark_struct {
+
+	var results []benchmark_struct
+
+	// the zero signature fails the signature check before mempool, only the rejection round trip is measured
+	thx, _ := json.Marshal(map[string]interface{}{
+		"sender":                    address,
+		"sequence_number":           "0",
+		"max_gas_amount":            "1000",
+		"gas_unit_price":            "100",
+		"expiration_timestamp_secs": fmt.Sprintf("%d", time.Now().Unix()+3600),
+		"payload": payload_struct{
+			Type:          "entry_function_payload",
+			Function:      "0x1::aptos_account::transfer",
+			TypeArguments: []string{},
+			Arguments:     []string{address, "0"},
+		},
+		"signature": map[string]string{
+			"type":       "ed25519_signature",
+			"public_key": public_key,
+			"signature":  "0x" + strings.Repeat("00", 64),
+		},
+	})
+
+	for _, url := range urls {
+		url = strings.TrimSuffix(strings.TrimSpace(url), "/")
+		if url == "" {
+			continue
+		}
+		client := new_client(url, 0)
+
+		requests := map[string]func() (*http.Request, error){
+			"ledger": func() (*http.Request, error) {
+				return http.NewRequest("GET", client.url, nil)
+			},
+			"account": func() (*http.Request, error) {
+				return http.NewRequest("GET", client.accounts+address, nil)
+			},
+			"simulate": func() (*http.Request, error) {
+				req, err := http.NewRequest("POST", client.simulate, bytes.NewReader(thx))
+				if err == nil {
+					req.Header.Add("Content-Type", "application/json")
+				}
+				return req, err
+			},
+			"reject": func() (*http.Request, error) {
+				req, err := http.NewRequest("POST", client.transactions, bytes.NewReader(thx))
+				if err == nil {
+					req.Header.Add("Content-Type", "application/json")
+				}
+				return req, err
+			},
+		}
+
+		for _, step := range benchmark_steps {
+			result := benchmark_struct{node: url, step: step}
+
+			for i := 0; i < rounds; i++ {
+				req, err := requests[step]()
+				if err != nil {
+					result.errors++
+					continue
+				}
+
+				start := time.Now()
+				res, err := benchmark_client.Do(req)
+				if err != nil {
+					result.errors++
+					continue
+				}
+				ioutil.ReadAll(res.Body)
+				res.Body.Close()
+
+				// rejected transactions still measure the round trip, server errors do not
+				if res.StatusCode >= 500 {
+					result.errors++
+					continue
+				}
+				result.samples = append(result.samples, time.Since(start))
+			}
+
+			results = append(results, result)
+		}
+	}
+
+	return results
+}
+
+// percentile uses the nearest rank method
+func percentile(samples []time.Duration, p float64) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+
+	sorted := append([]time.Duration{}, samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+
+	return sorted[rank]
+}
+
+func print_benchmark(results []benchmark_struct) {
+
+	ms := func(d time.Duration) string {
+		return strconv.FormatFloat(float64(d.Microseconds())/1000, 'f', 1, 64)
+	}
+
+	fmt.Printf("%-45s %-9s %8s %8s %8s %8s %8s %6s\n", "NODE", "STEP", "MIN", "P50", "P90", "P99", "MAX", "ERR")
+
+	for _, result := range results {
+		if len(result.samples) == 0 {
+			fmt.Printf("%-45s %-9s %8s %8s %8s %8s %8s %6s\n", result.node, result.step, "-", "-", "-", "-", "-", strconv.Itoa(result.errors))
+			continue
+		}
+
+		fmt.Printf("%-45s %-9s %8s %8s %8s %8s %8s %6d\n",
+			result.node,
+			result.step,
+			ms(percentile(result.samples, 0)),
+			ms(percentile(result.samples, 50)),
+			ms(percentile(result.samples, 90)),
+			ms(percentile(result.samples, 99)),
+			ms(percentile(result.samples, 100)),
+			result.errors,
+		)
+	}
+}
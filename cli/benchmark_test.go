@@ -0,0 +1,107 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBenchmarkNodes(t *testing.T) {
+
+	var mutex sync.Mutex
+	hits := map[string]int{}
+
+	// simulate fails with a server error every other round, reject answers the zero signature with a 400
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		hits[r.Method+" "+r.URL.Path]++
+		count := hits[r.Method+" "+r.URL.Path]
+		mutex.Unlock()
+
+		switch {
+		case r.URL.Path == "/v1/transactions/simulate" && count%2 == 0:
+			http.Error(w, `{"message":"internal"}`, http.StatusInternalServerError)
+		case r.URL.Path == "/v1/transactions":
+			http.Error(w, `{"message":"Invalid transaction: INVALID_SIGNATURE"}`, http.StatusBadRequest)
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	address := "0x" + strings.Repeat("01", 32)
+	results := benchmark_nodes([]string{server.URL + "/v1/", " "}, address, "0x"+strings.Repeat("02", 32), 4)
+
+	if len(results) != len(benchmark_steps) {
+		t.Fatalf("%d results, want one per step for the single node", len(results))
+	}
+
+	want := map[string]struct{ samples, errors int }{
+		"ledger":   {4, 0},
+		"account":  {4, 0},
+		"simulate": {2, 2},
+		"reject":   {4, 0},
+	}
+	for i, result := range results {
+		if result.node != server.URL+"/v1" || result.step != benchmark_steps[i] {
+			t.Errorf("result %d is %s %s", i, result.node, result.step)
+		}
+		if len(result.samples) != want[result.step].samples || result.errors != want[result.step].errors {
+			t.Errorf("%s: %d samples %d errors, want %d and %d",
+				result.step, len(result.samples), result.errors, want[result.step].samples, want[result.step].errors)
+		}
+	}
+
+	if hits["GET /v1/accounts/"+address] != 4 || hits["POST /v1/transactions/simulate"] != 4 || hits["POST /v1/transactions"] != 4 {
+		t.Errorf("requests %v", hits)
+	}
+}
+
+func TestBenchmarkUnreachable(t *testing.T) {
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	for _, result := range benchmark_nodes([]string{url}, "0x1", "0x2", 2) {
+		if len(result.samples) != 0 || result.errors != 2 {
+			t.Errorf("%s: %d samples %d errors, want 0 and 2", result.step, len(result.samples), result.errors)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+
+	var samples []time.Duration
+	for _, ms := range []int{9, 1, 10, 5, 3, 2, 8, 4, 7, 6} {
+		samples = append(samples, time.Duration(ms)*time.Millisecond)
+	}
+
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{0, 1 * time.Millisecond},
+		{10, 1 * time.Millisecond},
+		{50, 5 * time.Millisecond},
+		{90, 9 * time.Millisecond},
+		{99, 10 * time.Millisecond},
+		{100, 10 * time.Millisecond},
+	}
+
+	for _, test := range tests {
+		if got := percentile(samples, test.p); got != test.want {
+			t.Errorf("p%v = %s, want %s", test.p, got, test.want)
+		}
+	}
+
+	if samples[0] != 9*time.Millisecond {
+		t.Error("percentile sorted the samples in place")
+	}
+	if percentile(nil, 50) != 0 {
+		t.Error("percentile of no samples must be 0")
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gookit/color"
+)
+
+/*
+--------------------Broadcast--------------------
+*/
+type submit_result_struct struct {
+	node    string
+	status  int
+	body    []byte
+	latency time.Duration
+	err     error
+}
+
+// a hung node must not hold the broadcast, the first 202 returns early anyway
+var broadcast_client = &http.Client{Timeout: 10 * time.Second}
+
+// submit_signed broadcasts the bcs transaction, json when configured or when a node rejects bcs
+func submit_signed(Config *config_struct, signed_transaction []byte, thx map[string]interface{}, hash string) (int, []byte, error) {
+
+	if Config.Submit_json {
+		txn_request, _ := json.Marshal(thx)
+		return broadcast_transaction(Config, txn_request, "application/json", hash)
+	}
+
+	status, body, err := broadcast_transaction(Config, signed_transaction, "application/x.aptos.signed_transaction+bcs", hash)
+
+	// json fallback for nodes without bcs submission
+	if err == nil && bcs_unsupported(status, body) {
+		fmt.Printf("[%s] [%s] %s\n",
+			color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+			color.Yellow.Text("INFO   "),
+			color.Yellow.Text("BCS submission rejected, fallback to json"),
+		)
+
+		txn_request, _ := json.Marshal(thx)
+		return broadcast_transaction(Config, txn_request, "application/json", hash)
+	}
+
+	return status, body, err
+}
+
+// broadcast_transaction submits to every node in parallel and returns the first accepted response
+func broadcast_transaction(Config *config_struct, txn_request []byte, content_type string, hash string) (int, []byte, error) {
+
+	var nodes []string
+	for _, client := range Config.node_pool.healthy() {
+		nodes = append(nodes, client.transactions)
+	}
+	results := make(chan submit_result_struct, len(nodes))
+
+	for _, node := range nodes {
+		go func(node string) {
+			result := submit_transaction(node, txn_request, content_type)
+			log_submit_result(result, hash)
+			results <- result
+		}(node)
+	}
+
+	var first *submit_result_struct
+	duplicate := false
+
+	for range nodes {
+		result := <-results
+
+		if result.status == 202 {
+			return result.status, result.body, nil
+		}
+		if result.err == nil && submit_duplicate(result.body, hash) {
+			duplicate = true
+			continue
+		}
+		if first == nil || result.node == nodes[0] {
+			result := result
+			first = &result
+		}
+	}
+
+	// other nodes already hold the transaction in mempool
+	if duplicate {
+		body, _ := json.Marshal(map[string]string{"hash": hash})
+		return 202, body, nil
+	}
+
+	if first == nil {
+		return 0, nil, errors.New("broadcast: no node response")
+	}
+
+	return first.status, first.body, first.err
+}
+
+func submit_transaction(node string, txn_request []byte, content_type string) submit_result_struct {
+
+	result := submit_result_struct{node: node}
+	start := time.Now()
+
+	req, _ := http.NewRequest("POST", node, bytes.NewReader(txn_request))
+	req.Header.Add("Content-Type", content_type)
+
+	res, err := broadcast_client.Do(req)
+	result.latency = time.Since(start)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	defer res.Body.Close()
+
+	result.status = res.StatusCode
+	result.body, result.err = ioutil.ReadAll(res.Body)
+	result.latency = time.Since(start)
+
+	return result
+}
+
+// submit_duplicate matches the node holding this very transaction, a different payload
+// with the same sequence number is a conflict and not a success
+func submit_duplicate(body []byte, hash string) bool {
+	var response struct {
+		Message string `json:"message"`
+		Hash    string `json:"hash"`
+	}
+	json.Unmarshal(body, &response)
+
+	if response.Hash != "" {
+		return strings.EqualFold(response.Hash, hash)
+	}
+
+	message := strings.ToLower(response.Message)
+
+	return strings.Contains(message, "already in mempool") && !strings.Contains(message, "different")
+}
+
+// bcs_unsupported detects nodes that can not decode bcs submissions
+func bcs_unsupported(status int, body []byte) bool {
+	if status == 415 {
+		return true
+	}
+	if status != 400 {
+		return false
+	}
+
+	var response struct {
+		Message string `json:"message"`
+	}
+	json.Unmarshal(body, &response)
+	message := strings.ToLower(response.Message)
+
+	return strings.Contains(message, "deserialize") || strings.Contains(message, "bcs") || strings.Contains(message, "content type")
+}
+
+func log_submit_result(result submit_result_struct, hash string) {
+
+	var status string
+	switch {
+	case result.err != nil:
+		status = color.Red.Text("failed")
+	case result.status == 202:
+		status = color.Green.Text("accepted")
+	case submit_duplicate(result.body, hash):
+		status = color.Green.Text("duplicate")
+	default:
+		status = color.Red.Text(fmt.Sprintf("rejected %d", result.status))
+	}
+
+	fmt.Printf("[%s] [%s] %s\n",
+		color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+		color.Yellow.Text("INFO   "),
+		fmt.Sprintf("Node %s %s in %dms", strings.TrimSuffix(result.node, "/transactions"), status, result.latency.Milliseconds()),
+	)
+}
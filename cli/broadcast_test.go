@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type fake_submit_struct struct {
+	content_type string
+	body         []byte
+}
+
+// fake_submit_node answers transaction submits with respond and records them
+func fake_submit_node(t *testing.T, respond func(content_type string) (int, string)) (*httptest.Server, func() []fake_submit_struct) {
+	t.Helper()
+
+	var mutex sync.Mutex
+	var submits []fake_submit_struct
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		mutex.Lock()
+		submits = append(submits, fake_submit_struct{content_type: r.Header.Get("Content-Type"), body: body})
+		mutex.Unlock()
+
+		status, response := respond(r.Header.Get("Content-Type"))
+		w.WriteHeader(status)
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []fake_submit_struct {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]fake_submit_struct{}, submits...)
+	}
+}
+
+// test_broadcast_config uses the servers as healthy nodes, the first one active
+func test_broadcast_config(servers ...*httptest.Server) *config_struct {
+
+	pool := &node_pool_struct{}
+	for _, server := range servers {
+		pool.nodes = append(pool.nodes, &node_struct{client: new_client(server.URL+"/v1", 1), healthy: true})
+	}
+	pool.active = pool.nodes[0]
+
+	return &config_struct{node_pool: pool}
+}
+
+var test_thx = map[string]interface{}{"sender": "0x1", "sequence_number": "7"}
+
+const test_bcs_content_type = "application/x.aptos.signed_transaction+bcs"
+
+func TestSubmitSignedBcs(t *testing.T) {
+
+	server, submits := fake_submit_node(t, func(string) (int, string) {
+		return 202, `{"hash":"0xaa"}`
+	})
+
+	status, body, err := submit_signed(test_broadcast_config(server), []byte{1, 2, 3}, test_thx, "0xaa")
+	if err != nil || status != 202 || string(body) != `{"hash":"0xaa"}` {
+		t.Fatalf("submit %d %s %v", status, body, err)
+	}
+
+	got := submits()
+	if len(got) != 1 || got[0].content_type != test_bcs_content_type || string(got[0].body) != "\x01\x02\x03" {
+		t.Errorf("submits %+v, want the bcs bytes once", got)
+	}
+}
+
+func TestSubmitSignedFallback(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{"415", 415, `{"message":"unsupported media type"}`},
+		{"400 deserialize", 400, `{"message":"Failed to deserialize input into SignedTransaction"}`},
+	}
+
+	for _, test := range tests {
+		server, submits := fake_submit_node(t, func(content_type string) (int, string) {
+			if content_type == test_bcs_content_type {
+				return test.status, test.body
+			}
+			return 202, `{"hash":"0xbb"}`
+		})
+
+		status, _, err := submit_signed(test_broadcast_config(server), []byte{1}, test_thx, "0xbb")
+		if err != nil || status != 202 {
+			t.Errorf("%s: submit %d %v, want 202 after fallback", test.name, status, err)
+		}
+
+		got := submits()
+		if len(got) != 2 || got[1].content_type != "application/json" {
+			t.Fatalf("%s: submits %+v, want bcs then json", test.name, got)
+		}
+
+		var thx map[string]interface{}
+		if err := json.Unmarshal(got[1].body, &thx); err != nil || thx["sequence_number"] != "7" {
+			t.Errorf("%s: json body %s", test.name, got[1].body)
+		}
+	}
+
+	// other rejections are returned as they are
+	server, submits := fake_submit_node(t, func(string) (int, string) {
+		return 400, `{"message":"Invalid transaction: SEQUENCE_NUMBER_TOO_OLD"}`
+	})
+	status, _, err := submit_signed(test_broadcast_config(server), []byte{1}, test_thx, "0xcc")
+	if err != nil || status != 400 || len(submits()) != 1 {
+		t.Errorf("validation error: submit %d %v with %d submits, want 400 once", status, err, len(submits()))
+	}
+}
+
+func TestSubmitJson(t *testing.T) {
+
+	server, submits := fake_submit_node(t, func(string) (int, string) {
+		return 202, `{"hash":"0xdd"}`
+	})
+
+	Config := test_broadcast_config(server)
+	Config.Submit_json = true
+
+	if status, _, err := submit_signed(Config, []byte{1}, test_thx, "0xdd"); err != nil || status != 202 {
+		t.Fatalf("submit %d %v", status, err)
+	}
+
+	got := submits()
+	if len(got) != 1 || got[0].content_type != "application/json" {
+		t.Errorf("submits %+v, want json only", got)
+	}
+}
+
+func TestBroadcastDuplicate(t *testing.T) {
+
+	// the transaction reached mempool through another path, every node reports it
+	duplicate := func(string) (int, string) {
+		return 400, `{"message":"Transaction already in mempool"}`
+	}
+	first, _ := fake_submit_node(t, duplicate)
+	second, _ := fake_submit_node(t, duplicate)
+
+	status, body, err := broadcast_transaction(test_broadcast_config(first, second), []byte{1}, test_bcs_content_type, "0xee")
+	if err != nil || status != 202 || string(body) != `{"hash":"0xee"}` {
+		t.Errorf("broadcast %d %s %v, want 202 with the hash", status, body, err)
+	}
+
+	// one rejection and one duplicate still count as submitted
+	rejected, _ := fake_submit_node(t, func(string) (int, string) {
+		return 400, `{"message":"Invalid transaction: INVALID_SIGNATURE"}`
+	})
+	status, _, err = broadcast_transaction(test_broadcast_config(rejected, first), []byte{1}, test_bcs_content_type, "0xee")
+	if err != nil || status != 202 {
+		t.Errorf("broadcast %d %v, want 202", status, err)
+	}
+
+	// same sequence number with another payload is a conflict, not our transaction
+	conflict, _ := fake_submit_node(t, func(string) (int, string) {
+		return 400, `{"message":"Transaction already in mempool with a different payload"}`
+	})
+	status, _, _ = broadcast_transaction(test_broadcast_config(conflict), []byte{1}, test_bcs_content_type, "0xee")
+	if status == 202 {
+		t.Error("conflicting payload reported as accepted")
+	}
+
+	// a node naming another hash holds another transaction
+	other_hash, _ := fake_submit_node(t, func(string) (int, string) {
+		return 400, `{"message":"Transaction already in mempool","hash":"0xff"}`
+	})
+	status, _, _ = broadcast_transaction(test_broadcast_config(other_hash), []byte{1}, test_bcs_content_type, "0xee")
+	if status == 202 {
+		t.Error("duplicate of another hash reported as accepted")
+	}
+
+	// without any duplicate the active node answer is returned
+	other, _ := fake_submit_node(t, func(string) (int, string) {
+		return 500, `{"message":"internal"}`
+	})
+	status, body, _ = broadcast_transaction(test_broadcast_config(rejected, other), []byte{1}, test_bcs_content_type, "0xee")
+	if status != 400 || string(body) != `{"message":"Invalid transaction: INVALID_SIGNATURE"}` {
+		t.Errorf("broadcast %d %s, want the active node rejection", status, body)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gookit/color"
+)
+
+/*
+--------------------Confirmation--------------------
+*/
+const (
+	txn_pending   = "pending"
+	txn_committed = "committed"
+	txn_failed    = "failed"
+	txn_expired   = "expired"
+)
+
+type txn_result_struct struct {
+	status    string
+	hash      string
+	vm_status string
+}
+
+// wait_transaction polls the node until the transaction is committed or its expiration passed
+func wait_transaction(Config *config_struct, hash string, expiration_timestamp_secs int64) txn_result_struct {
+
+	result := txn_result_struct{status: txn_pending, hash: hash}
+
+	// wait_by_hash long polls on the node, by_hash is the fallback for nodes without it
+	without_wait := map[string]bool{}
+	backoff := 200 * time.Millisecond
+	reported := false
+
+	for {
+		// the active node changes on failover
+		client := Config.node()
+		endpoint := client.wait
+		if without_wait[client.url] {
+			endpoint = client.result
+		}
+
+		status, response, err := fetch_transaction(endpoint + hash)
+
+		switch {
+		case err != nil:
+			// node error, keep polling until expiration
+
+		case status == 404 && response.Error_code != "transaction_not_found" && endpoint == client.wait:
+			without_wait[client.url] = true
+			continue
+
+		case status == 200 && response.Type != "pending_transaction":
+			if response.Success {
+				result.status = txn_committed
+			} else {
+				result.status = txn_failed
+				result.vm_status = response.Vm_status
+			}
+
+			return result
+
+		case status == 200 && !reported:
+			fmt.Printf("[%s] [%s] %s\n",
+				color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+				color.Yellow.Text("INFO   "),
+				color.Yellow.Text("Transaction pending thx: "+hash),
+			)
+			reported = true
+		}
+
+		// a transaction not committed one second after expiration never will be
+		if time.Now().Unix() > expiration_timestamp_secs+1 {
+			if status, response, err := fetch_transaction(Config.node().result + hash); err == nil && status == 200 && response.Type != "pending_transaction" {
+				if response.Success {
+					result.status = txn_committed
+				} else {
+					result.status = txn_failed
+					result.vm_status = response.Vm_status
+				}
+
+				return result
+			}
+
+			result.status = txn_expired
+			return result
+		}
+
+		time.Sleep(backoff)
+		if backoff < 2000*time.Millisecond {
+			backoff *= 2
+		}
+	}
+}
+
+type txn_response_struct struct {
+	Type       string `json:"type"`
+	Success    bool   `json:"success"`
+	Vm_status  string `json:"vm_status"`
+	Message    string `json:"message"`
+	Error_code string `json:"error_code"`
+}
+
+func fetch_transaction(url string) (int, txn_response_struct, error) {
+
+	var response txn_response_struct
+
+	req, _ := http.NewRequest("GET", url, nil)
+	res, err := node_client.Do(req)
+	if err != nil {
+		return 0, response, err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return res.StatusCode, response, err
+	}
+	json.Unmarshal(body, &response)
+
+	return res.StatusCode, response, nil
+}
+
+// report_transaction prints the final outcome and forwards it to the notifiers
+func report_transaction(Config *config_struct, result txn_result_struct, nft_info nft_info) {
+
+	var message string
+
+	switch result.status {
+	case txn_committed:
+		message = func() string {
+			switch nft_info.mode {
+			case "sniper":
+				return "Successfully purchased " + nft_info.token_name + " for " + fmt.Sprintf("%f", nft_info.price/100_000_000)
+			case "minter":
+				return "Successfully minted " + nft_info.token_name + " from " + nft_info.collection
+			case "sweep":
+				return "Successfully swept " + nft_info.token_name + " for " + fmt.Sprintf("%f", nft_info.price/100_000_000)
+			case "custom":
+				return "Successfully executed " + nft_info.token_name
+			default:
+				return "Successfully purchased"
+			}
+		}()
+
+		fmt.Printf("[%s] [%s] %s\n",
+			color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+			color.Green.Text("SUCCESS"),
+			color.Green.Text(message),
+		)
+
+		go Config.refresh_balance()
+
+	case txn_failed:
+		message = "Faild purchased: " + result.vm_status
+
+		fmt.Printf("[%s] [%s] %s\n",
+			color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+			color.Red.Text("ERROR  "),
+			color.Red.Text(message),
+		)
+
+		// gas is spent even when the purchase aborts
+		go Config.refresh_balance()
+
+	case txn_expired:
+		message = "Transaction expired thx: " + result.hash
+
+		fmt.Printf("[%s] [%s] %s\n",
+			color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+			color.Red.Text("ERROR  "),
+			color.Red.Text(message),
+		)
+
+	default:
+		message = "Transaction pending thx: " + result.hash
+
+		fmt.Printf("[%s] [%s] %s\n",
+			color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+			color.Yellow.Text("INFO   "),
+			color.Yellow.Text(message),
+		)
+	}
+
+	if err := send_discord(Config, result, nft_info, message); err != nil {
+		fmt.Printf("[%s] [%s] %s\n",
+			color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+			color.Red.Text("ERROR  "),
+			color.Red.Text(err.Error()),
+		)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fake_transaction_node answers wait_by_hash and by_hash, a nil handler answers 404 not found
+func fake_transaction_node(t *testing.T, wait http.HandlerFunc, by_hash http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	not_found := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(404)
+		w.Write([]byte(`{"message":"Transaction not found","error_code":"transaction_not_found"}`))
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/v1/transactions/wait_by_hash/") && wait != nil:
+			wait(w, r)
+		case strings.HasPrefix(r.URL.Path, "/v1/transactions/by_hash/") && by_hash != nil:
+			by_hash(w, r)
+		default:
+			not_found(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func respond_with(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}
+}
+
+const (
+	test_txn_committed = `{"type":"user_transaction","success":true,"vm_status":"Executed successfully"}`
+	test_txn_failed    = `{"type":"user_transaction","success":false,"vm_status":"Move abort in 0x1::coin: EINSUFFICIENT_BALANCE(0x10006)"}`
+	test_txn_pending   = `{"type":"pending_transaction"}`
+)
+
+func TestWaitTransaction(t *testing.T) {
+
+	later := time.Now().Unix() + 30
+
+	tests := []struct {
+		name       string
+		wait       http.HandlerFunc
+		by_hash    http.HandlerFunc
+		expiration int64
+		status     string
+		vm_status  string
+	}{
+		{"committed", respond_with(test_txn_committed), nil, later, txn_committed, ""},
+		{"failed", respond_with(test_txn_failed), nil, later, txn_failed, "Move abort in 0x1::coin: EINSUFFICIENT_BALANCE(0x10006)"},
+		{"expired", nil, nil, time.Now().Unix() - 5, txn_expired, ""},
+		{"pending past expiration", respond_with(test_txn_pending), respond_with(test_txn_pending), time.Now().Unix() - 5, txn_expired, ""},
+		// committed while the last poll was pending, by_hash is asked once more at expiration
+		{"committed at expiration", respond_with(test_txn_pending), respond_with(test_txn_committed), time.Now().Unix() - 5, txn_committed, ""},
+		// nodes without wait_by_hash answer a plain 404
+		{"by_hash fallback", func(w http.ResponseWriter, r *http.Request) {
+			http.NotFound(w, r)
+		}, respond_with(test_txn_committed), later, txn_committed, ""},
+	}
+
+	for _, test := range tests {
+		server := fake_transaction_node(t, test.wait, test.by_hash)
+
+		result := wait_transaction(test_broadcast_config(server), "0xaa", test.expiration)
+		if result.status != test.status || result.vm_status != test.vm_status || result.hash != "0xaa" {
+			t.Errorf("%s: %+v, want %s %q", test.name, result, test.status, test.vm_status)
+		}
+	}
+}
+
+func TestWaitTransactionFailover(t *testing.T) {
+
+	var Config *config_struct
+
+	// the first node stalls on pending and fails over, the second one has the committed transaction
+	var polls int32
+	stalled := fake_transaction_node(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&polls, 1) == 2 {
+			Config.node_pool.mutex.Lock()
+			Config.node_pool.active = Config.node_pool.nodes[1]
+			Config.node_pool.mutex.Unlock()
+		}
+		w.Write([]byte(test_txn_pending))
+	}, nil)
+	healthy := fake_transaction_node(t, respond_with(test_txn_committed), nil)
+
+	Config = test_broadcast_config(stalled, healthy)
+
+	result := wait_transaction(Config, "0xbb", time.Now().Unix()+30)
+	if result.status != txn_committed {
+		t.Errorf("%+v, want committed from the new active node", result)
+	}
+	if atomic.LoadInt32(&polls) != 2 {
+		t.Errorf("%d polls on the stalled node, want 2", atomic.LoadInt32(&polls))
+	}
+}
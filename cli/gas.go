@@ -0,0 +1,282 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/paulrademacher/climenu"
+)
+
+/*
+--------------------Gas--------------------
+*/
+const (
+	gas_deprioritized = "deprioritized"
+	gas_normal        = "normal"
+	gas_prioritized   = "prioritized"
+)
+
+type gas_rule_struct struct {
+	Tier       string  `json:"tier"`
+	Multiplier float64 `json:"multiplier"`
+}
+
+type gas_config_struct struct {
+	Tier           string                     `json:"tier"`
+	Multiplier     float64                    `json:"multiplier"`
+	Max_gas_amount uint64                     `json:"max_gas_amount"`
+	Marketplaces   map[string]gas_rule_struct `json:"marketplaces"`
+	Collections    map[string]gas_rule_struct `json:"collections"`
+}
+
+// gas_price_struct caches /estimate_gas_price so sending never waits on it
+type gas_price_struct struct {
+	mutex         sync.RWMutex
+	deprioritized uint64
+	normal        uint64
+	prioritized   uint64
+	updated       time.Time
+	done          chan struct{}
+}
+
+func (gas *gas_config_struct) defaults() {
+	if gas.Tier == "" {
+		gas.Tier = gas_normal
+	}
+	if gas.Multiplier <= 0 {
+		gas.Multiplier = 1
+	}
+	if gas.Max_gas_amount == 0 {
+		gas.Max_gas_amount = 100000
+	}
+}
+
+// new_gas_price needs a first estimate from the node, prices are never guessed
+func new_gas_price(Config *config_struct) (*gas_price_struct, error) {
+
+	gas_price := &gas_price_struct{done: make(chan struct{})}
+	if err := gas_price.refresh(Config.node().gas); err != nil {
+		return nil, err
+	}
+
+	return gas_price, nil
+}
+
+// start refreshes the estimate of the active node in background, the last one is kept on error
+func (gas_price *gas_price_struct) start(Config *config_struct, interval time.Duration) {
+	go func() {
+		for {
+			select {
+			case <-gas_price.done:
+				return
+			case <-time.After(interval):
+				gas_price.refresh(Config.node().gas)
+			}
+		}
+	}()
+}
+
+func (gas_price *gas_price_struct) stop() {
+	close(gas_price.done)
+}
+
+func (gas_price *gas_price_struct) refresh(url string) error {
+
+	req, _ := http.NewRequest("GET", url, nil)
+	res, err := node_client.Do(req)
+	if err != nil {
+		return errors.New("gas: node request error")
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil || res.StatusCode != 200 {
+		return errors.New("gas: error read estimate")
+	}
+
+	var response struct {
+		Deprioritized uint64 `json:"deprioritized_gas_estimate"`
+		Normal        uint64 `json:"gas_estimate"`
+		Prioritized   uint64 `json:"prioritized_gas_estimate"`
+	}
+	if err = json.Unmarshal(body, &response); err != nil || response.Normal == 0 {
+		return errors.New("gas: error decode estimate")
+	}
+
+	gas_price.mutex.Lock()
+	defer gas_price.mutex.Unlock()
+
+	gas_price.normal = response.Normal
+	gas_price.deprioritized = response.Deprioritized
+	if gas_price.deprioritized == 0 {
+		gas_price.deprioritized = response.Normal
+	}
+	gas_price.prioritized = response.Prioritized
+	if gas_price.prioritized == 0 {
+		gas_price.prioritized = response.Normal
+	}
+	gas_price.updated = time.Now()
+
+	return nil
+}
+
+func (gas_price *gas_price_struct) tier(tier string) uint64 {
+	gas_price.mutex.RLock()
+	defer gas_price.mutex.RUnlock()
+
+	switch tier {
+	case gas_deprioritized:
+		return gas_price.deprioritized
+	case gas_prioritized:
+		return gas_price.prioritized
+	default:
+		return gas_price.normal
+	}
+}
+
+// gas_unit_price resolves collection, then marketplace, then global rule
+func (Config *config_struct) gas_unit_price(marketplace string, collection string) uint64 {
+
+	rule := gas_rule_struct{Tier: Config.Gas.Tier, Multiplier: Config.Gas.Multiplier}
+	if override, ok := Config.Gas.Marketplaces[marketplace]; ok {
+		rule = override
+	}
+	if override, ok := Config.Gas.Collections[collection]; ok {
+		rule = override
+	}
+	if rule.Multiplier <= 0 {
+		rule.Multiplier = 1
+	}
+
+	return uint64(math.Ceil(float64(Config.gas_price.tier(rule.Tier)) * rule.Multiplier))
+}
+
+/*
+----------Settings----------
+*/
+func settings_gas(Config *config_struct) {
+
+	Clear(3, "action > settings > gas", "info")
+
+	for {
+		menu := climenu.NewButtonMenu("", "Choose action")
+		menu.AddMenuItem("Tier ["+Config.Gas.Tier+"]", "tier")
+		menu.AddMenuItem("Multiplier ["+strconv.FormatFloat(Config.Gas.Multiplier, 'f', -1, 64)+"]", "multiplier")
+		menu.AddMenuItem("Max gas amount ["+strconv.FormatUint(Config.Gas.Max_gas_amount, 10)+"]", "max_gas_amount")
+		menu.AddMenuItem("Marketplace rule", "marketplace")
+		menu.AddMenuItem("Collection rule", "collection")
+
+		action, escaped := menu.Run()
+		if escaped {
+			return
+		}
+
+		switch action {
+		case "tier":
+			Clear(4, "action > settings > gas > tier", "info")
+			if tier, ok := ask_gas_tier(false); ok {
+				Config.Gas.Tier = tier
+			}
+			Clear(4, "action > settings > gas", "info")
+
+		case "multiplier":
+			Clear(4, "action > settings > gas > multiplier", "info")
+			if multiplier, ok := ask_gas_multiplier(); ok {
+				Config.Gas.Multiplier = multiplier
+			}
+			Clear(2, "action > settings > gas", "info")
+
+		case "max_gas_amount":
+			Clear(4, "action > settings > gas > max gas amount", "info")
+			input := climenu.GetText("Max gas amount", "eg: 100000")
+			if max_gas_amount, err := strconv.ParseUint(input, 10, 64); err == nil && max_gas_amount > 0 {
+				Config.Gas.Max_gas_amount = max_gas_amount
+			}
+			Clear(2, "action > settings > gas", "info")
+
+		case "marketplace":
+			Clear(4, "action > settings > gas > marketplace", "info")
+			menu := climenu.NewButtonMenu("", "Choose marketplace")
+			menu.AddMenuItem("Topaz", "topaz")
+			menu.AddMenuItem("BlueMove", "bluemove")
+
+			marketplace, escaped := menu.Run()
+			Clear(4, nil, nil)
+			if !escaped {
+				if Config.Gas.Marketplaces == nil {
+					Config.Gas.Marketplaces = map[string]gas_rule_struct{}
+				}
+				ask_gas_rule(Config.Gas.Marketplaces, marketplace)
+			}
+			Clear(1, "action > settings > gas", "info")
+
+		case "collection":
+			Clear(4, "action > settings > gas > collection", "info")
+			collection := climenu.GetText("Collection name", "eg: Bruh Bears")
+			Clear(1, nil, nil)
+			if collection != "" {
+				if Config.Gas.Collections == nil {
+					Config.Gas.Collections = map[string]gas_rule_struct{}
+				}
+				ask_gas_rule(Config.Gas.Collections, collection)
+			}
+			Clear(1, "action > settings > gas", "info")
+		}
+
+		if err := Config.dump_config(); err != nil {
+		}
+	}
+}
+
+func ask_gas_rule(rules map[string]gas_rule_struct, key string) {
+
+	tier, ok := ask_gas_tier(true)
+	if !ok {
+		return
+	}
+	if tier == "" {
+		delete(rules, key)
+		return
+	}
+
+	multiplier, ok := ask_gas_multiplier()
+	Clear(1, nil, nil)
+	if !ok {
+		return
+	}
+
+	rules[key] = gas_rule_struct{Tier: tier, Multiplier: multiplier}
+}
+
+func ask_gas_tier(removable bool) (string, bool) {
+
+	menu := climenu.NewButtonMenu("", "Gas tier")
+	menu.AddMenuItem("Deprioritized", gas_deprioritized)
+	menu.AddMenuItem("Normal", gas_normal)
+	menu.AddMenuItem("Prioritized", gas_prioritized)
+	if removable {
+		menu.AddMenuItem("Use default", "")
+	}
+
+	tier, escaped := menu.Run()
+	Clear(3, nil, nil)
+
+	return tier, !escaped
+}
+
+func ask_gas_multiplier() (float64, bool) {
+
+	input := climenu.GetText("Gas multiplier", "eg: 1.5")
+	multiplier, err := strconv.ParseFloat(input, 64)
+	if err != nil || multiplier <= 0 {
+		return 0, false
+	}
+
+	return multiplier, true
+}
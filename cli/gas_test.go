@@ -0,0 +1,145 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func fake_gas_node(t *testing.T, status int, body string) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var estimates int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/estimate_gas_price" {
+			http.NotFound(w, r)
+			return
+		}
+		atomic.AddInt32(&estimates, 1)
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &estimates
+}
+
+func TestNewGasPrice(t *testing.T) {
+
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		fails         bool
+		deprioritized uint64
+		normal        uint64
+		prioritized   uint64
+	}{
+		{"all tiers", 200, `{"deprioritized_gas_estimate":100,"gas_estimate":150,"prioritized_gas_estimate":300}`, false, 100, 150, 300},
+		// missing tiers fall back to the normal estimate
+		{"normal only", 200, `{"gas_estimate":120}`, false, 120, 120, 120},
+		// no estimate means no price, never a guessed one
+		{"zero estimate", 200, `{"gas_estimate":0}`, true, 0, 0, 0},
+		{"node error", 500, `{"message":"internal error"}`, true, 0, 0, 0},
+		{"bad body", 200, `not json`, true, 0, 0, 0},
+	}
+
+	for _, test := range tests {
+		server, _ := fake_gas_node(t, test.status, test.body)
+
+		gas_price, err := new_gas_price(test_broadcast_config(server))
+		if test.fails {
+			if err == nil {
+				t.Errorf("%s: want an error, got %+v", test.name, gas_price)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		if got := gas_price.tier(gas_deprioritized); got != test.deprioritized {
+			t.Errorf("%s: deprioritized %d, want %d", test.name, got, test.deprioritized)
+		}
+		if got := gas_price.tier(gas_normal); got != test.normal {
+			t.Errorf("%s: normal %d, want %d", test.name, got, test.normal)
+		}
+		if got := gas_price.tier(gas_prioritized); got != test.prioritized {
+			t.Errorf("%s: prioritized %d, want %d", test.name, got, test.prioritized)
+		}
+	}
+}
+
+func TestGasPriceStop(t *testing.T) {
+
+	server, estimates := fake_gas_node(t, 200, `{"gas_estimate":100}`)
+	Config := test_broadcast_config(server)
+
+	gas_price, err := new_gas_price(Config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gas_price.start(Config, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	gas_price.stop()
+
+	// an in flight refresh may still finish after stop
+	time.Sleep(20 * time.Millisecond)
+	stopped := atomic.LoadInt32(estimates)
+	if stopped < 2 {
+		t.Errorf("%d estimates, want the refresher to run", stopped)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if got := atomic.LoadInt32(estimates); got != stopped {
+		t.Errorf("%d estimates after stop, want %d", got, stopped)
+	}
+}
+
+func TestGasUnitPrice(t *testing.T) {
+
+	server, _ := fake_gas_node(t, 200, `{"deprioritized_gas_estimate":100,"gas_estimate":150,"prioritized_gas_estimate":301}`)
+	Config := test_broadcast_config(server)
+
+	gas_price, err := new_gas_price(Config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Config.gas_price = gas_price
+
+	Config.Gas = gas_config_struct{
+		Tier:       gas_normal,
+		Multiplier: 1.25,
+		Marketplaces: map[string]gas_rule_struct{
+			"topaz": {Tier: gas_prioritized, Multiplier: 1.5},
+		},
+		Collections: map[string]gas_rule_struct{
+			"Bruh Bears": {Tier: gas_deprioritized},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		marketplace string
+		collection  string
+		price       uint64
+	}{
+		// 150 * 1.25 rounded up
+		{"global rule", "bluemove", "Aptos Monkeys", 188},
+		// 301 * 1.5 rounded up
+		{"marketplace rule", "topaz", "Aptos Monkeys", 452},
+		// the collection wins over the marketplace, a missing multiplier is 1
+		{"collection rule", "topaz", "Bruh Bears", 100},
+		{"collection rule other marketplace", "bluemove", "Bruh Bears", 100},
+	}
+
+	for _, test := range tests {
+		if got := Config.gas_unit_price(test.marketplace, test.collection); got != test.price {
+			t.Errorf("%s: %d, want %d", test.name, got, test.price)
+		}
+	}
+}
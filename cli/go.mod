@@ -2,14 +2,17 @@ module cli
 
 go 1.19
 
+require (
+	github.com/gookit/color v1.5.2
+	github.com/nsf/termbox-go v1.1.1
+	github.com/paulrademacher/climenu v0.0.0-20151110221007-a1afbb4e378b
+	golang.org/x/crypto v0.3.0
+)
+
 require (
 	github.com/buger/goterm v1.0.4 // indirect
-	github.com/gookit/color v1.5.2 // indirect
 	github.com/mattn/go-runewidth v0.0.9 // indirect
-	github.com/nsf/termbox-go v1.1.1 // indirect
-	github.com/paulrademacher/climenu v0.0.0-20151110221007-a1afbb4e378b // indirect
 	github.com/pkg/term v1.1.0 // indirect
 	github.com/xo/terminfo v0.0.0-20210125001918-ca9a967f8778 // indirect
-	golang.org/x/crypto v0.3.0 // indirect
 	golang.org/x/sys v0.2.0 // indirect
 )
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gookit/color"
+)
+
+/*
+--------------------Headless--------------------
+*/
+type list_flag []string
+
+func (list *list_flag) String() string {
+	return strings.Join(*list, ",")
+}
+
+func (list *list_flag) Set(value string) error {
+	*list = append(*list, value)
+	return nil
+}
+
+// headless runs a command without the interactive menu and returns the exit code
+func headless(args []string) int {
+
+	switch args[0] {
+	case "benchmark":
+		return headless_benchmark(args[1:])
+	case "signer":
+		return headless_signer(args[1:])
+	case "wallets":
+		return headless_wallets(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\nusage: cli [benchmark|signer|wallets]\n", args[0])
+		return 2
+	}
+}
+
+func headless_benchmark(args []string) int {
+
+	var nodes list_flag
+
+	flags := flag.NewFlagSet("benchmark", flag.ContinueOnError)
+	flags.Var(&nodes, "node", "node url, repeat for several nodes (default: nodes from config.json)")
+	rounds := flags.Int("rounds", 20, "requests per node and step")
+	address := flags.String("address", "", "account used for account, simulate and submit (default: config wallet)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	public_key := "0x" + strings.Repeat("00", 32)
+
+	if len(nodes) == 0 {
+		var Config config_struct
+		if err := Config.load_config(); err != nil {
+			color.Warn.Tips(err.Error())
+			return 1
+		}
+
+		nodes = Config.network().Nodes
+		if *address == "" {
+			*address = Config.wallet.address_str
+			public_key = Config.wallet.publicKeyStr
+		}
+	}
+
+	if *address == "" {
+		*address = "0x1"
+	}
+
+	print_benchmark(benchmark_nodes(nodes, *address, public_key, *rounds))
+
+	return 0
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/gookit/color"
+	"golang.org/x/crypto/scrypt"
+)
+
+/*
+--------------------Keystore--------------------
+*/
+
+// passphrase for headless runs, the prompt is skipped when set
+const keystore_passphrase_env = "APTOS_SNIPER_PASSPHRASE"
+
+// secrets kept encrypted in the keystore, never in config.json
+type keystore_secrets_struct struct {
+	Key          string   `json:"aptos_private_key,omitempty"`
+	Mnemonic     string   `json:"aptos_mnemonic,omitempty"`
+	Gas_key      string   `json:"gas_wallet_private_key,omitempty"`
+	Multisig_key []string `json:"multisig_private_keys,omitempty"`
+	// generated or imported from the wallets menu
+	Wallets []keystore_wallet_struct `json:"wallets,omitempty"`
+}
+
+type keystore_wallet_struct struct {
+	Name string `json:"name"`
+	Key  string `json:"aptos_private_key"`
+}
+
+type keystore_file_struct struct {
+	Version    int    `json:"version"`
+	Kdf        string `json:"kdf"`
+	Kdf_params struct {
+		N    int    `json:"n"`
+		R    int    `json:"r"`
+		P    int    `json:"p"`
+		Salt string `json:"salt"`
+	} `json:"kdf_params"`
+	Cipher     string `json:"cipher"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// open_keystore decrypts the secrets with the passphrase
+func open_keystore(path string, passphrase string) (keystore_secrets_struct, error) {
+
+	var secrets keystore_secrets_struct
+	var file keystore_file_struct
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return secrets, errors.New("keystore: error read " + path)
+	}
+	if err = json.Unmarshal(data, &file); err != nil || file.Kdf != "scrypt" || file.Cipher != "aes-256-gcm" {
+		return secrets, errors.New("keystore: unsupported file " + path)
+	}
+
+	salt, err := hex.DecodeString(file.Kdf_params.Salt)
+	if err != nil {
+		return secrets, errors.New("keystore: wrong salt")
+	}
+	nonce, err := hex.DecodeString(file.Nonce)
+	if err != nil {
+		return secrets, errors.New("keystore: wrong nonce")
+	}
+	ciphertext, err := hex.DecodeString(file.Ciphertext)
+	if err != nil {
+		return secrets, errors.New("keystore: wrong ciphertext")
+	}
+
+	key, err := scrypt.Key([]byte(passphrase), salt, file.Kdf_params.N, file.Kdf_params.R, file.Kdf_params.P, 32)
+	if err != nil {
+		return secrets, errors.New("keystore: " + err.Error())
+	}
+
+	block, _ := aes.NewCipher(key)
+	gcm, _ := cipher.NewGCM(block)
+	if len(nonce) != gcm.NonceSize() {
+		return secrets, errors.New("keystore: wrong nonce")
+	}
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return secrets, errors.New("keystore: wrong passphrase")
+	}
+
+	if err = json.Unmarshal(plaintext, &secrets); err != nil {
+		return secrets, errors.New("keystore: error decode secrets")
+	}
+
+	return secrets, nil
+}
+
+// save_keystore encrypts the secrets with a fresh salt and nonce, readable by the owner only
+func save_keystore(path string, passphrase string, secrets keystore_secrets_struct) error {
+
+	var file keystore_file_struct
+	file.Version = 1
+	file.Kdf = "scrypt"
+	file.Kdf_params.N = 1 << 17
+	file.Kdf_params.R = 8
+	file.Kdf_params.P = 1
+	file.Cipher = "aes-256-gcm"
+
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return errors.New("keystore: error read random")
+	}
+
+	key, err := scrypt.Key([]byte(passphrase), salt, file.Kdf_params.N, file.Kdf_params.R, file.Kdf_params.P, 32)
+	if err != nil {
+		return errors.New("keystore: " + err.Error())
+	}
+
+	block, _ := aes.NewCipher(key)
+	gcm, _ := cipher.NewGCM(block)
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return errors.New("keystore: error read random")
+	}
+
+	plaintext, _ := json.Marshal(secrets)
+
+	file.Kdf_params.Salt = hex.EncodeToString(salt)
+	file.Nonce = hex.EncodeToString(nonce)
+	file.Ciphertext = hex.EncodeToString(gcm.Seal(nil, nonce, plaintext, nil))
+
+	data, _ := json.MarshalIndent(file, "", "  ")
+
+	// write aside and rename so a failed write never loses the keys
+	if err := os.WriteFile(path+".tmp", data, 0600); err != nil {
+		return errors.New("keystore: error write " + path)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return errors.New("keystore: error write " + path)
+	}
+
+	return nil
+}
+
+// read_passphrase reads without echo, from the environment when set
+func read_passphrase(prompt string) string {
+
+	if passphrase, ok := os.LookupEnv(keystore_passphrase_env); ok {
+		return passphrase
+	}
+
+	return read_secret(prompt)
+}
+
+// read_secret reads a line without echo
+func read_secret(prompt string) string {
+
+	fmt.Print(color.Magenta.Text(prompt + ": "))
+
+	stty := func(args ...string) {
+		cmd := exec.Command("stty", args...)
+		cmd.Stdin = os.Stdin
+		cmd.Run()
+	}
+	stty("-echo")
+	defer stty("echo")
+
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	fmt.Println()
+
+	return strings.TrimRight(line, "\r\n")
+}
+
+// keystore_path resolves the keystore next to config.json
+func keystore_path(dir string, path string) string {
+	if path == "" {
+		path = "keystore.json"
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	return path
+}
+
+// plaintext_secrets are the private keys written in config.json
+func (Config *config_struct) plaintext_secrets() keystore_secrets_struct {
+	return keystore_secrets_struct{
+		Key:          Config.Key,
+		Mnemonic:     Config.Mnemonic,
+		Gas_key:      Config.Gas_wallet.Key,
+		Multisig_key: Config.Multisig.Keys,
+	}
+}
+
+func (secrets keystore_secrets_struct) empty() bool {
+	return secrets.Key == "" && secrets.Mnemonic == "" && secrets.Gas_key == "" && len(secrets.Multisig_key) == 0
+}
+
+// merge replaces the stored keys by the plaintext keys of config.json
+func (secrets *keystore_secrets_struct) merge(plaintext keystore_secrets_struct) {
+	if plaintext.Key != "" || plaintext.Mnemonic != "" {
+		secrets.Key = plaintext.Key
+		secrets.Mnemonic = plaintext.Mnemonic
+	}
+	if plaintext.Gas_key != "" {
+		secrets.Gas_key = plaintext.Gas_key
+	}
+	if len(plaintext.Multisig_key) > 0 {
+		secrets.Multisig_key = plaintext.Multisig_key
+	}
+}
+
+// unlock_keystore loads the private keys from the keystore, plaintext keys of config.json are moved into it
+func (Config *config_struct) unlock_keystore(dir string) error {
+
+	plaintext := Config.plaintext_secrets()
+
+	if Config.Keystore == "" && plaintext.empty() {
+		return errors.New("no private key, add aptos_private_key to config.json to import it into the keystore")
+	}
+
+	path := keystore_path(dir, Config.Keystore)
+
+	if _, err := os.Stat(path); err != nil {
+		if plaintext.empty() {
+			return errors.New("keystore: " + path + " not found")
+		}
+		color.Info.Tips("private keys in config.json are moved to an encrypted keystore")
+	}
+
+	secrets, passphrase, err := ask_keystore(path)
+	if err != nil {
+		return err
+	}
+
+	// migrate plaintext keys, config.json only keeps the keystore path
+	if !plaintext.empty() {
+		secrets.merge(plaintext)
+
+		if err := save_keystore(path, passphrase, secrets); err != nil {
+			return err
+		}
+
+		if Config.Keystore == "" {
+			Config.Keystore = filepath.Base(path)
+		}
+		if err := Config.dump_config(); err != nil {
+			return err
+		}
+
+		color.Info.Tips("private keys moved to " + path)
+	}
+
+	Config.Key = secrets.Key
+	Config.Mnemonic = secrets.Mnemonic
+	Config.Gas_wallet.Key = secrets.Gas_key
+	Config.Multisig.Keys = secrets.Multisig_key
+	Config.stored_wallets = secrets.Wallets
+
+	return nil
+}
+
+// ask_keystore opens the keystore with its passphrase, the passphrase of a new keystore is asked twice
+func ask_keystore(path string) (keystore_secrets_struct, string, error) {
+
+	if _, err := os.Stat(path); err == nil {
+		passphrase := read_passphrase("Keystore passphrase")
+
+		secrets, err := open_keystore(path, passphrase)
+		return secrets, passphrase, err
+	}
+
+	passphrase := read_passphrase("New keystore passphrase")
+	if passphrase == "" {
+		return keystore_secrets_struct{}, "", errors.New("keystore: empty passphrase")
+	}
+	if _, ok := os.LookupEnv(keystore_passphrase_env); !ok && read_passphrase("Repeat passphrase") != passphrase {
+		return keystore_secrets_struct{}, "", errors.New("keystore: passphrases do not match")
+	}
+
+	return keystore_secrets_struct{}, passphrase, nil
+}
+
+// add_keystore_wallet stores a wallet key and returns its name, the keystore is created when missing
+func (Config *config_struct) add_keystore_wallet(dir string, wallet keystore_wallet_struct) (string, error) {
+
+	if Config.Remote_signer.Url != "" {
+		return "", errors.New("wallets are kept on the remote signer, add them to its keystore")
+	}
+
+	path := keystore_path(dir, Config.Keystore)
+
+	secrets, passphrase, err := ask_keystore(path)
+	if err != nil {
+		return "", err
+	}
+
+	// plaintext keys of config.json are moved along, they are removed by dump_config below
+	secrets.merge(Config.plaintext_secrets())
+
+	if wallet.Name == "" {
+		wallet.Name = fmt.Sprintf("wallet-%d", len(secrets.Wallets)+1)
+	}
+
+	if wallet.Name == "account" || wallet.Name == "gas_wallet" {
+		return "", errors.New("keystore: wallet name " + wallet.Name + " is reserved")
+	}
+	for _, stored := range secrets.Wallets {
+		if stored.Name == wallet.Name {
+			return "", errors.New("keystore: wallet " + wallet.Name + " already exists")
+		}
+		if strings.TrimPrefix(stored.Key, "0x") == strings.TrimPrefix(wallet.Key, "0x") {
+			return "", errors.New("keystore: key already stored as " + stored.Name)
+		}
+	}
+	secrets.Wallets = append(secrets.Wallets, wallet)
+
+	if err := save_keystore(path, passphrase, secrets); err != nil {
+		return "", err
+	}
+
+	if Config.Keystore == "" {
+		Config.Keystore = filepath.Base(path)
+	}
+	if err := Config.dump_config(); err != nil {
+		return "", err
+	}
+	Config.stored_wallets = secrets.Wallets
+
+	return wallet.Name, nil
+}
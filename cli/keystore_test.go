@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var test_secrets = keystore_secrets_struct{
+	Key:          "0x" + strings.Repeat("11", 32),
+	Gas_key:      "0x" + strings.Repeat("22", 32),
+	Multisig_key: []string{"0x" + strings.Repeat("33", 32)},
+	Wallets:      []keystore_wallet_struct{{Name: "wallet-1", Key: "0x" + strings.Repeat("44", 32)}},
+}
+
+func TestKeystoreRoundTrip(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "keystore.json")
+
+	if err := save_keystore(path, "correct horse", test_secrets); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("keystore mode %v %v, want 0600", info.Mode().Perm(), err)
+	}
+	if _, err := os.Stat(path + ".tmp"); err == nil {
+		t.Error("temporary keystore left behind")
+	}
+
+	// nothing readable without the passphrase
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), strings.Repeat("11", 32)) || strings.Contains(string(data), "wallet-1") {
+		t.Error("secrets written in clear")
+	}
+
+	secrets, err := open_keystore(path, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(secrets, test_secrets) {
+		t.Errorf("opened %+v, want %+v", secrets, test_secrets)
+	}
+}
+
+func TestKeystoreWrongPassphrase(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "keystore.json")
+	if err := save_keystore(path, "correct horse", test_secrets); err != nil {
+		t.Fatal(err)
+	}
+
+	secrets, err := open_keystore(path, "wrong horse")
+	if err == nil || err.Error() != "keystore: wrong passphrase" {
+		t.Errorf("error %v, want wrong passphrase", err)
+	}
+	if !secrets.empty() || len(secrets.Wallets) != 0 {
+		t.Errorf("secrets %+v returned with a wrong passphrase", secrets)
+	}
+
+	// a modified ciphertext fails the same way
+	var file keystore_file_struct
+	data, _ := os.ReadFile(path)
+	json.Unmarshal(data, &file)
+	file.Ciphertext = strings.Repeat("0", len(file.Ciphertext))
+	data, _ = json.Marshal(file)
+	os.WriteFile(path, data, 0600)
+
+	if _, err := open_keystore(path, "correct horse"); err == nil {
+		t.Error("opened a modified keystore")
+	}
+
+	if _, err := open_keystore(filepath.Join(t.TempDir(), "missing.json"), "correct horse"); err == nil {
+		t.Error("opened a missing keystore")
+	}
+}
+
+func TestUnlockKeystoreMigration(t *testing.T) {
+
+	dir := t.TempDir()
+	t.Setenv(keystore_passphrase_env, "correct horse")
+
+	// dump_config writes config.json next to the executable
+	args := os.Args[0]
+	os.Args[0] = filepath.Join(dir, "cli")
+	defer func() { os.Args[0] = args }()
+
+	Config := &config_struct{}
+	Config.Key = test_secrets.Key
+	Config.Gas_wallet.Key = test_secrets.Gas_key
+
+	if err := Config.unlock_keystore(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if Config.Keystore != "keystore.json" || Config.Key != test_secrets.Key || Config.Gas_wallet.Key != test_secrets.Gas_key {
+		t.Errorf("config after unlock %q %q %q", Config.Keystore, Config.Key, Config.Gas_wallet.Key)
+	}
+
+	// config.json only keeps the keystore path
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), strings.Repeat("11", 32)) || strings.Contains(string(data), strings.Repeat("22", 32)) {
+		t.Errorf("private keys left in config.json: %s", data)
+	}
+	var dumped config_struct
+	if json.Unmarshal(data, &dumped) != nil || dumped.Keystore != "keystore.json" {
+		t.Errorf("config.json without the keystore path: %s", data)
+	}
+
+	secrets, err := open_keystore(filepath.Join(dir, "keystore.json"), "correct horse")
+	if err != nil || secrets.Key != test_secrets.Key || secrets.Gas_key != test_secrets.Gas_key {
+		t.Errorf("keystore %+v %v, want the migrated keys", secrets, err)
+	}
+
+	// the next start reads the keys from the keystore only
+	next := &config_struct{}
+	next.Keystore = "keystore.json"
+	if err := next.unlock_keystore(dir); err != nil || next.Key != test_secrets.Key || next.Gas_wallet.Key != test_secrets.Gas_key {
+		t.Errorf("unlock from keystore %q %q %v", next.Key, next.Gas_wallet.Key, err)
+	}
+
+	// a wrong passphrase keeps the keys locked
+	t.Setenv(keystore_passphrase_env, "wrong horse")
+	locked := &config_struct{}
+	locked.Keystore = "keystore.json"
+	if err := locked.unlock_keystore(dir); err == nil || locked.Key != "" {
+		t.Errorf("unlocked %q with a wrong passphrase", locked.Key)
+	}
+}
@@ -1,9 +1,7 @@
 package main
 
 import (
-	"bytes"
 	"crypto/ed25519"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -19,7 +17,6 @@ import (
 	"github.com/gookit/color"
 	term "github.com/nsf/termbox-go"
 	"github.com/paulrademacher/climenu"
-	"golang.org/x/crypto/sha3"
 )
 
 // constant cli
@@ -27,9 +24,30 @@ const Version string = "1.0.0"
 const Command = "clear"
 
 type config_struct struct {
-	Node    string `json:"aptos_node_url"`
-	Key     string `json:"aptos_private_key"`
-	Discord struct {
+	Node        string   `json:"aptos_node_url"`
+	Broadcast   []string `json:"aptos_broadcast_node_urls"`
+	Keystore    string   `json:"keystore,omitempty"` // encrypted private keys, next to config.json
+	Key         string   `json:"aptos_private_key"`
+	Address     string   `json:"aptos_account_address,omitempty"` // rotated keys only
+	Mnemonic    string   `json:"aptos_mnemonic,omitempty"`
+	Index       uint32   `json:"aptos_account_index,omitempty"` // m/44'/637'/index'/0'/0'
+	Submit_json bool     `json:"submit_json"`
+	Gas_wallet  struct {
+		Key     string `json:"aptos_private_key"`
+		Address string `json:"aptos_account_address,omitempty"`
+	} `json:"gas_wallet"`
+	Multisig struct {
+		Keys        []string `json:"aptos_private_keys,omitempty"`
+		Public_keys []string `json:"public_keys"`
+		Threshold   uint8    `json:"threshold"`
+	} `json:"multisig"`
+	Remote_signer struct {
+		Url   string `json:"url,omitempty"` // keys stay on the signing host
+		Token string `json:"token,omitempty"`
+	} `json:"remote_signer"`
+	Network  string                            `json:"network"`
+	Networks map[string]network_profile_struct `json:"networks"`
+	Discord  struct {
 		Send_fail bool   `json:"send_fail"`
 		Hook      string `json:"hook"`
 	} `json:"discord_hook"`
@@ -37,44 +55,75 @@ type config_struct struct {
 		Topaz    collection_info_struct `json:"topaz"`
 		Bluemove collection_info_struct `json:"bluemove"`
 	} `json:"last_run_collection"`
-	wallet struct {
-		balance         string
-		privateKey      ed25519.PrivateKey
-		publicKey       ed25519.PublicKey
-		address         [32]byte
-		privateKeyStr   string
-		publicKeyStr    string
-		address_str     string
-		sequence_number int
-	}
-	client struct {
-		url          string //https://fullnode.mainnet.aptoslabs.com/v1
-		accounts     string //https://fullnode.mainnet.aptoslabs.com/v1/accounts/
-		encode       string //https://fullnode.mainnet.aptoslabs.com/v1/transaction/encode_submission
-		transactions string //https://fullnode.mainnet.aptoslabs.com/v1/transactions
-		result       string //https://fullnode.mainnet.aptoslabs.com/v1/transactions/by_hash/<<HASH>>
+	Gas        gas_config_struct `json:"gas"`
+	gas_price  *gas_price_struct
+	wallet     wallet_struct
+	gas_wallet *wallet_struct
+	node_pool  *node_pool_struct
+	wallets    *wallet_pool_struct
+	session    struct {
+		simulate    bool
+		fee_payer   bool
+		sweep       bool
+		header      bool
+		round_robin bool
 	}
+	// wallets of the keystore besides the account and gas wallet
+	stored_wallets []keystore_wallet_struct
+}
+
+type wallet_struct struct {
+	balance       *balance_struct
+	privateKey    ed25519.PrivateKey
+	publicKey     ed25519.PublicKey
+	auth_key      [32]byte // differs from address once the key was rotated
+	address       [32]byte
+	privateKeyStr string
+	publicKeyStr  string
+	address_str   string
+	sequence      *sequence_manager_struct
+	signer        signer_struct // local key, MultiEd25519 keys or remote signer, private keys above unused by the last two
+	name          string        // keystore or remote signer name, round robin wallets only
 }
 
 type payload_struct struct {
-	Type          string      `json:"type"`
-	Function      string      `json:"function"`
-	TypeArguments []string    `json:"type_arguments"`
-	Arguments     interface{} `json:"arguments"`
+	Type          string       `json:"type"`
+	Function      string       `json:"function,omitempty"`
+	Code          *code_struct `json:"code,omitempty"`
+	TypeArguments []string     `json:"type_arguments"`
+	Arguments     interface{}  `json:"arguments"`
+	ArgumentTypes []string     `json:"-"`
+}
+
+// script bytecode, script_payload only
+type code_struct struct {
+	Bytecode string `json:"bytecode"`
 }
 
 type collection_info_struct struct {
 	Name    string `json:"name"`
 	ID      string `json:"id"`
 	Creator string `json:"creator"`
+	Version string `json:"token_version"`
 }
 
 type nft_info struct {
-	token_name string
-	price      float64
-	rank       int
-	image      string
-	mode       string
+	token_name  string
+	price       float64
+	rank        int
+	image       string
+	mode        string
+	marketplace string
+	collection  string
+	creator     string
+	// token v1 property version, token v2 object address when known
+	property_version string
+	token_address    string
+	seller           string
+	// buyer, set by send_transaction
+	wallet *wallet_struct
+	// listings bought by a sweep, each one is verified
+	swept []nft_info
 }
 
 type topaz_listing_struct struct {
@@ -91,6 +140,8 @@ type topaz_listing_struct struct {
 		UpdatedAT    string  `json:"updated_at"`
 		PreviewURI   string  `json:"preview_uri"`
 		Rank         string  `json:"string,rank"`
+		// token v2 listing object, token_id is then the token object address
+		ListingID string `json:"listing_id"`
 	} `json:"data"`
 }
 
@@ -108,6 +159,9 @@ type bluemove_listing_struct struct {
 				Value     string `json:"value"`
 				TraitType string `json:"trait_type"`
 			} `json:"attributes"`
+			// token v2 listing and token objects
+			ListingID string `json:"listing_id"`
+			TokenID   string `json:"token_id"`
 		} `json:"attributes"`
 	} `json:"data"`
 	Meta struct {
@@ -140,6 +194,10 @@ type bluemove_collections_struct struct {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		os.Exit(headless(os.Args[1:]))
+	}
+
 	Clear(4, nil, nil)
 
 	var Config config_struct
@@ -148,7 +206,7 @@ func main() {
 		os.Exit(0)
 	}
 
-	logo(Config.wallet.balance)
+	logo(Config.wallet.balance.String(), Config.node().url+" ["+Config.network_name()+"]")
 
 	color.Grayf("Use arrows \u2191 \u2193 to navigate, space to select and enter to confirm and esc to back")
 
@@ -157,6 +215,8 @@ func main() {
 
 		menu := climenu.NewButtonMenu("", "Choose an action")
 		menu.AddMenuItem("Aptos sniper", "aptos_sniper")
+		menu.AddMenuItem("Wallets", "wallets")
+		menu.AddMenuItem("Benchmark nodes", "benchmark")
 		menu.AddMenuItem("Settings", "settings")
 
 		action, escaped := menu.Run()
@@ -169,6 +229,12 @@ func main() {
 		case "aptos_sniper":
 			aptos_sniper(&Config)
 			Clear(4, nil, nil)
+		case "wallets":
+			wallets(&Config)
+			Clear(4, nil, nil)
+		case "benchmark":
+			benchmark(&Config)
+			Clear(4, nil, nil)
 		case "settings":
 			settings(&Config)
 			Clear(3, nil, nil)
@@ -187,6 +253,8 @@ func aptos_sniper(Config *config_struct) {
 		menu := climenu.NewButtonMenu("", "Choose marketplace")
 		menu.AddMenuItem("Topaz", "topaz_sniper")
 		menu.AddMenuItem("BlueMove", "bluemove_sniper")
+		menu.AddMenuItem("Minter", "aptos_minter")
+		menu.AddMenuItem("Custom function", "custom_function")
 
 		action, escaped := menu.Run()
 		if escaped {
@@ -198,6 +266,10 @@ func aptos_sniper(Config *config_struct) {
 			topaz_sniper(Config)
 		case "bluemove_sniper":
 			bluemove_sniper(Config)
+		case "aptos_minter":
+			aptos_minter(Config)
+		case "custom_function":
+			custom_function(Config)
 		}
 	}
 }
@@ -209,6 +281,17 @@ func topaz_sniper(Config *config_struct) {
 
 	Clear(4, "action > aptos sniper > topaz", "info")
 
+	if Config.network().Contracts.Topaz == "" && Config.network().Contracts.Topaz_v2 == "" {
+		color.Warn.Tips("topaz contract not configured for " + Config.network_name() + ". Press enter for back.")
+		fmt.Scanln()
+		return
+	}
+	if Config.network().Apis.Topaz == "" {
+		color.Warn.Tips("topaz api not configured for " + Config.network_name() + ". Press enter for back.")
+		fmt.Scanln()
+		return
+	}
+
 	var collection_info collection_info_struct
 
 	// dump config collection to collection_info
@@ -221,7 +304,7 @@ func topaz_sniper(Config *config_struct) {
 
 		collection_name := climenu.GetText("Topaz collection", "eg: Bruh-Bears-43ec2cb158")
 
-		if err := collection_info.topaz_get_collection_id(collection_name); err != nil {
+		if err := collection_info.topaz_get_collection_id(Config.network().Apis.Topaz, collection_name); err != nil {
 			color.Warn.Tips(err.Error())
 			fmt.Scanln()
 			return
@@ -251,7 +334,7 @@ func topaz_sniper(Config *config_struct) {
 
 			collection_name := climenu.GetText("Topaz collection", "eg: Bruh-Bears-43ec2cb158")
 
-			if err := collection_info.topaz_get_collection_id(collection_name); err != nil {
+			if err := collection_info.topaz_get_collection_id(Config.network().Apis.Topaz, collection_name); err != nil {
 				color.Warn.Tips(err.Error())
 				fmt.Scanln()
 				return
@@ -268,10 +351,36 @@ func topaz_sniper(Config *config_struct) {
 		}
 	}
 
+	// token standard of the collection decides the buy function
+	version := Config.token_version(&collection_info)
+	if version != Config.Collection.Topaz.Version {
+		Config.Collection.Topaz.Version = version
+		if err := Config.dump_config(); err != nil {
+		}
+	}
+
+	if (version == token_v1 && Config.network().Contracts.Topaz == "") ||
+		(version == token_v2 && Config.network().Contracts.Topaz_v2 == "") {
+		color.Warn.Tips("topaz token " + version + " contract not configured for " + Config.network_name() + ". Press enter for back.")
+		fmt.Scanln()
+		return
+	}
+
+	// the token v2 function is configured by hand, check it before buying with it
+	if version == token_v2 {
+		payload, _ := topaz_payload(Config, nft_info{token_address: collection_info.Creator}, "", collection_info.Creator)
+		if err := check_v2_payload(Config.node(), payload); err != nil {
+			color.Warn.Tips("topaz_v2: " + err.Error() + ". Press enter for back.")
+			fmt.Scanln()
+			return
+		}
+	}
+
 	var sniped_price float64
 	var err error
 
 	fmt.Printf("%s %s\n", color.Magenta.Text("Collection"), collection_info.Name)
+	fmt.Printf("%s %s\n", color.Magenta.Text("Token     "), version)
 	for {
 		input := climenu.GetText("Max price sniped", "eg: 0.5")
 		sniped_price, err = strconv.ParseFloat(input, 64)
@@ -283,14 +392,42 @@ func topaz_sniper(Config *config_struct) {
 		}
 	}
 
+	if !ask_simulate(Config) {
+		return
+	}
+
+	if !ask_fee_payer(Config) {
+		return
+	}
+
+	if !ask_sweep(Config, "topaz", version) {
+		return
+	}
+
+	if !ask_round_robin(Config) {
+		return
+	}
+
 	// create new workspace for sniper in terminal
 	term.Init()
 	defer term.Close()
 
-	logo(Config.wallet.balance)
+	logo(Config.wallet.balance.String(), Config.node().url+" ["+Config.network_name()+"]")
+	pin_header(Config)
 	Clear(0, "action > aptos sniper > topaz", "info")
 	fmt.Printf("%s %s\n", color.Magenta.Text("Collection"), collection_info.Name)
+	fmt.Printf("%s %s\n", color.Magenta.Text("Token     "), version)
 	fmt.Printf("%s %f\n", color.Magenta.Text("Max price "), sniped_price/100_000_000)
+	fmt.Printf("%s %t\n", color.Magenta.Text("Simulate  "), Config.session.simulate)
+	if Config.session.fee_payer {
+		fmt.Printf("%s %s\n", color.Magenta.Text("Gas wallet"), Config.gas_wallet.address_str)
+	}
+	if Config.session.sweep {
+		fmt.Printf("%s %t\n", color.Magenta.Text("Sweep     "), Config.session.sweep)
+	}
+	if Config.session.round_robin {
+		fmt.Printf("%s %d\n", color.Magenta.Text("Wallets   "), len(Config.wallets.ready()))
+	}
 
 	fmt.Printf("[%s] [%s] %s\n",
 		color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
@@ -299,7 +436,7 @@ func topaz_sniper(Config *config_struct) {
 	)
 
 	sniper := func(escaped *bool) {
-		url := fmt.Sprintf("https://api-v1.topaz.so/api/listing-view-p?collection_id=%s&from=0&to=49&sort_mode=PRICE_LOW_TO_HIGH&buy_now=false&page=0&min_price=undefined&max_price=null&filters={}&search=null", collection_info.ID)
+		url := fmt.Sprintf("%s/listing-view-p?collection_id=%s&from=0&to=49&sort_mode=PRICE_LOW_TO_HIGH&buy_now=false&page=0&min_price=undefined&max_price=null&filters={}&search=null", Config.network().Apis.Topaz, collection_info.ID)
 		req, _ := http.NewRequest("GET", url, nil)
 		// try mint list
 		var try_buy_nft []string
@@ -358,6 +495,9 @@ func topaz_sniper(Config *config_struct) {
 				continue
 			}
 
+			// listings of this poll bought together when sweeping
+			var sweep []nft_info
+
 			for _, listing := range response.Data {
 
 				if listing.Price <= sniped_price {
@@ -374,34 +514,51 @@ func topaz_sniper(Config *config_struct) {
 
 					if !in_try_buy_nft {
 
-						go send_transaction(
-							Config,
-
-							payload_struct{
-								Type:     "entry_function_payload",
-								Function: "0x2c7bccf7b31baf770fdbcc768d9e9cb3d87805e255355df5db32ac9a669010a2::marketplace_v2::buy",
-								TypeArguments: []string{
-									"0x1::aptos_coin::AptosCoin",
-								},
-								Arguments: []string{
-									listing.Seller,
-									fmt.Sprintf("%d", int(listing.Price)),
-									"1",
-									collection_info.Creator,
-									collection_info.Name,
-									listing.TokenName,
-									"0",
-								},
-							},
-
-							nft_info{
-								token_name: listing.TokenName,
-								price:      listing.Price,
-								rank:       1,
-								image:      listing.PreviewURI,
-								mode:       "sniper",
-							},
-						)
+						nft_info := nft_info{
+							token_name:       listing.TokenName,
+							price:            listing.Price,
+							rank:             1,
+							image:            listing.PreviewURI,
+							mode:             "sniper",
+							marketplace:      "topaz",
+							collection:       collection_info.Name,
+							creator:          collection_info.Creator,
+							property_version: topaz_property_version(listing.TokenID),
+							seller:           listing.Seller,
+						}
+						if version == token_v2 {
+							token_address, err := v2_listing_token("topaz", listing.ListingID, listing.TokenID)
+							if err != nil {
+								fmt.Printf("[%s] [%s] %s\n",
+									color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+									color.Red.Text("ERROR  "),
+									color.Red.Text(err.Error()),
+								)
+
+								try_buy_nft = append(try_buy_nft, listing.UpdatedAT)
+								continue
+							}
+							nft_info.property_version = ""
+							nft_info.token_address = token_address
+						}
+
+						if Config.session.sweep {
+							sweep = append(sweep, nft_info)
+						} else {
+							payload, err := topaz_payload(Config, nft_info, listing.Seller, listing.ListingID)
+							if err != nil {
+								fmt.Printf("[%s] [%s] %s\n",
+									color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+									color.Red.Text("ERROR  "),
+									color.Red.Text(err.Error()),
+								)
+
+								try_buy_nft = append(try_buy_nft, listing.UpdatedAT)
+								continue
+							}
+
+							go send_transaction(Config, payload, nft_info)
+						}
 
 						fmt.Printf("[%s] [%s] %s\n",
 							color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
@@ -414,6 +571,10 @@ func topaz_sniper(Config *config_struct) {
 					}
 				}
 			}
+			if len(sweep) > 0 {
+				go send_sweep(Config, sweep)
+			}
+
 			// cool down
 			time.Sleep(1000 * time.Millisecond)
 		}
@@ -437,6 +598,7 @@ func topaz_sniper(Config *config_struct) {
 					"Sniper stopped",
 				)
 
+				unpin_header(Config)
 				term.Close()
 				return
 			}
@@ -444,9 +606,9 @@ func topaz_sniper(Config *config_struct) {
 	}
 }
 
-func (collection_info *collection_info_struct) topaz_get_collection_id(collection_name string) error {
+func (collection_info *collection_info_struct) topaz_get_collection_id(api string, collection_name string) error {
 
-	req, _ := http.NewRequest("GET", "https://api-v1.topaz.so/api/collection?slug="+collection_name, nil)
+	req, _ := http.NewRequest("GET", api+"/collection?slug="+collection_name, nil)
 	res, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return errors.New("error get collection id. Press enter for back.")
@@ -486,6 +648,17 @@ func bluemove_sniper(Config *config_struct) {
 
 	Clear(4, "action > aptos sniper > bluemove", "info")
 
+	if Config.network().Contracts.Bluemove == "" && Config.network().Contracts.Bluemove_v2 == "" {
+		color.Warn.Tips("bluemove contract not configured for " + Config.network_name() + ". Press enter for back.")
+		fmt.Scanln()
+		return
+	}
+	if Config.network().Apis.Bluemove == "" {
+		color.Warn.Tips("bluemove api not configured for " + Config.network_name() + ". Press enter for back.")
+		fmt.Scanln()
+		return
+	}
+
 	var collection_info collection_info_struct
 
 	// dump config collection to collection_info
@@ -496,7 +669,7 @@ func bluemove_sniper(Config *config_struct) {
 
 		collection_info.ID = climenu.GetText("Bluemove collection", "eg: bruh-bears")
 
-		if err := collection_info.bluemove_get_collection_id(collection_info.ID); err != nil {
+		if err := collection_info.bluemove_get_collection_id(Config.network().Apis.Bluemove, collection_info.ID); err != nil {
 			color.Warn.Tips(err.Error())
 			fmt.Scanln()
 			return
@@ -526,7 +699,7 @@ func bluemove_sniper(Config *config_struct) {
 
 			collection_info.ID = climenu.GetText("Bluemove collection", "eg: bruh-bears")
 
-			if err := collection_info.bluemove_get_collection_id(collection_info.ID); err != nil {
+			if err := collection_info.bluemove_get_collection_id(Config.network().Apis.Bluemove, collection_info.ID); err != nil {
 				color.Warn.Tips(err.Error())
 				fmt.Scanln()
 				return
@@ -543,10 +716,36 @@ func bluemove_sniper(Config *config_struct) {
 		}
 	}
 
+	// token standard of the collection decides the buy function
+	version := Config.token_version(&collection_info)
+	if version != Config.Collection.Bluemove.Version {
+		Config.Collection.Bluemove.Version = version
+		if err := Config.dump_config(); err != nil {
+		}
+	}
+
+	if (version == token_v1 && Config.network().Contracts.Bluemove == "") ||
+		(version == token_v2 && Config.network().Contracts.Bluemove_v2 == "") {
+		color.Warn.Tips("bluemove token " + version + " contract not configured for " + Config.network_name() + ". Press enter for back.")
+		fmt.Scanln()
+		return
+	}
+
+	// the token v2 function is configured by hand, check it before buying with it
+	if version == token_v2 {
+		payload, _ := bluemove_payload(Config, nft_info{token_address: collection_info.Creator}, collection_info.Creator)
+		if err := check_v2_payload(Config.node(), payload); err != nil {
+			color.Warn.Tips("bluemove_v2: " + err.Error() + ". Press enter for back.")
+			fmt.Scanln()
+			return
+		}
+	}
+
 	var sniped_price float64
 	var err error
 
 	fmt.Printf("%s %s\n", color.Magenta.Text("Collection"), collection_info.Name)
+	fmt.Printf("%s %s\n", color.Magenta.Text("Token     "), version)
 	for {
 		input := climenu.GetText("Max price sniped", "eg: 0.5")
 		sniped_price, err = strconv.ParseFloat(input, 64)
@@ -558,14 +757,42 @@ func bluemove_sniper(Config *config_struct) {
 		}
 	}
 
+	if !ask_simulate(Config) {
+		return
+	}
+
+	if !ask_fee_payer(Config) {
+		return
+	}
+
+	if !ask_sweep(Config, "bluemove", version) {
+		return
+	}
+
+	if !ask_round_robin(Config) {
+		return
+	}
+
 	// create new workspace for sniper in terminal
 	term.Init()
 	defer term.Close()
 
-	logo(Config.wallet.balance)
+	logo(Config.wallet.balance.String(), Config.node().url+" ["+Config.network_name()+"]")
+	pin_header(Config)
 	Clear(0, "action > aptos sniper > bluemove", "info")
 	fmt.Printf("%s %s\n", color.Magenta.Text("Collection"), collection_info.Name)
+	fmt.Printf("%s %s\n", color.Magenta.Text("Token     "), version)
 	fmt.Printf("%s %f\n", color.Magenta.Text("Max price "), sniped_price/100_000_000)
+	fmt.Printf("%s %t\n", color.Magenta.Text("Simulate  "), Config.session.simulate)
+	if Config.session.fee_payer {
+		fmt.Printf("%s %s\n", color.Magenta.Text("Gas wallet"), Config.gas_wallet.address_str)
+	}
+	if Config.session.sweep {
+		fmt.Printf("%s %t\n", color.Magenta.Text("Sweep     "), Config.session.sweep)
+	}
+	if Config.session.round_robin {
+		fmt.Printf("%s %d\n", color.Magenta.Text("Wallets   "), len(Config.wallets.ready()))
+	}
 
 	fmt.Printf("[%s] [%s] %s\n",
 		color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
@@ -574,7 +801,7 @@ func bluemove_sniper(Config *config_struct) {
 	)
 
 	sniper := func(escaped *bool) {
-		url := fmt.Sprintf("https://aptos-mainnet-api.bluemove.net/api/market-items?filters[collection][slug][$eq]=%s&filters[status][$eq]=1&filters[price][$gte]=0&filters[price][$lte]=%d&sort[0]=price:asc&pagination[page]=1&pagination[pageSize]=5", collection_info.ID, int(sniped_price))
+		url := fmt.Sprintf("%s/market-items?filters[collection][slug][$eq]=%s&filters[status][$eq]=1&filters[price][$gte]=0&filters[price][$lte]=%d&sort[0]=price:asc&pagination[page]=1&pagination[pageSize]=5", Config.network().Apis.Bluemove, collection_info.ID, int(sniped_price))
 
 		// try mint list
 		var try_buy_nft []string
@@ -635,6 +862,9 @@ func bluemove_sniper(Config *config_struct) {
 				continue
 			}
 
+			// listings of this poll bought together when sweeping
+			var sweep []nft_info
+
 			for _, listing := range response.Data {
 				if listing.Attributes.Price <= sniped_price {
 
@@ -650,37 +880,48 @@ func bluemove_sniper(Config *config_struct) {
 
 					if !in_try_buy_nft {
 
-						go send_transaction(
-							Config,
-
-							payload_struct{
-								Function:      "0xd1fd99c1944b84d1670a2536417e997864ad12303d19eac725891691b04d614e::marketplaceV2::batch_buy_script",
-								TypeArguments: []string{},
-								Arguments: [][]string{
-									{
-										collection_info.Creator,
-									},
-									{
-										collection_info.Name,
-									},
-									{
-										listing.Attributes.Name,
-									},
-									{
-										fmt.Sprintf("%d0", int(listing.Attributes.Price)),
-									},
-								},
-								Type: "entry_function_payload",
-							},
-
-							nft_info{
-								token_name: listing.Attributes.Name,
-								price:      listing.Attributes.Price,
-								rank:       listing.Attributes.Rank,
-								image:      listing.Attributes.URIMedia,
-								mode:       "sniper",
-							},
-						)
+						nft_info := nft_info{
+							token_name:  listing.Attributes.Name,
+							price:       listing.Attributes.Price,
+							rank:        listing.Attributes.Rank,
+							image:       listing.Attributes.URIMedia,
+							mode:        "sniper",
+							marketplace: "bluemove",
+							collection:  collection_info.Name,
+							creator:     collection_info.Creator,
+						}
+						if version == token_v2 {
+							token_address, err := v2_listing_token("bluemove", listing.Attributes.ListingID, listing.Attributes.TokenID)
+							if err != nil {
+								fmt.Printf("[%s] [%s] %s\n",
+									color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+									color.Red.Text("ERROR  "),
+									color.Red.Text(err.Error()),
+								)
+
+								try_buy_nft = append(try_buy_nft, listing.Attributes.UpdatedAt)
+								continue
+							}
+							nft_info.token_address = token_address
+						}
+
+						if Config.session.sweep {
+							sweep = append(sweep, nft_info)
+						} else {
+							payload, err := bluemove_payload(Config, nft_info, listing.Attributes.ListingID)
+							if err != nil {
+								fmt.Printf("[%s] [%s] %s\n",
+									color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+									color.Red.Text("ERROR  "),
+									color.Red.Text(err.Error()),
+								)
+
+								try_buy_nft = append(try_buy_nft, listing.Attributes.UpdatedAt)
+								continue
+							}
+
+							go send_transaction(Config, payload, nft_info)
+						}
 
 						fmt.Printf("[%s] [%s] %s\n",
 							color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
@@ -693,6 +934,10 @@ func bluemove_sniper(Config *config_struct) {
 					}
 				}
 			}
+			if len(sweep) > 0 {
+				go send_sweep(Config, sweep)
+			}
+
 			// cool down
 			time.Sleep(1000 * time.Millisecond)
 		}
@@ -716,6 +961,7 @@ func bluemove_sniper(Config *config_struct) {
 					"Sniper stopped",
 				)
 
+				unpin_header(Config)
 				term.Close()
 				return
 			}
@@ -723,9 +969,9 @@ func bluemove_sniper(Config *config_struct) {
 	}
 }
 
-func (collection_info *collection_info_struct) bluemove_get_collection_id(collection_id string) error {
+func (collection_info *collection_info_struct) bluemove_get_collection_id(api string, collection_id string) error {
 
-	url := "https://aptos-mainnet-api.bluemove.net/api/collections?sort[0]=total_volume:desc&pagination[page]=1&pagination[pageSize]=10000"
+	url := api + "/collections?sort[0]=total_volume:desc&pagination[page]=1&pagination[pageSize]=10000"
 
 	req, _ := http.NewRequest("GET", url, nil)
 	res, err := http.DefaultClient.Do(req)
@@ -767,167 +1013,227 @@ func (collection_info *collection_info_struct) bluemove_get_collection_id(collec
 */
 func send_transaction(Config *config_struct, payload payload_struct, nft_info nft_info) {
 
-	thx := map[string]interface{}{
-		"sender":                    Config.wallet.address_str,
-		"sequence_number":           fmt.Sprintf("%d", Config.wallet.sequence_number),
-		"max_gas_amount":            "100000",
-		"gas_unit_price":            "100",
-		"expiration_timestamp_secs": fmt.Sprintf("%d", time.Now().Unix()+600),
-		"payload":                   payload,
-		"signature":                 nil,
-	}
-
-	txn_request, _ := json.Marshal(thx)
-	req, _ := http.NewRequest("POST", Config.client.encode, bytes.NewReader(txn_request))
-	req.Header.Add("Content-Type", "application/json")
-
-	res, err := http.DefaultClient.Do(req)
-	if (err != nil) || (res.StatusCode != 200) {
+	transaction_payload, err := payload.transaction_payload()
+	if err != nil {
 		fmt.Printf("[%s] [%s] %s\n",
 			color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
 			color.Red.Text("ERROR  "),
-			color.Red.Text("Error encode submission"),
+			color.Red.Text("Error encode payload: "+err.Error()),
 		)
 
 		return
 	}
-	defer res.Body.Close()
 
-	var body []byte
-	if body, err = ioutil.ReadAll(res.Body); err != nil {
+	// reserve price and max gas so parallel buys never overdraw the wallet
+	amount := uint64(nft_info.price)
+	if !Config.session.fee_payer {
+		amount += Config.Gas.Max_gas_amount * Config.gas_unit_price(nft_info.marketplace, nft_info.collection)
+	}
+
+	wallet, reserved, err := Config.sender(nft_info, amount)
+	if err != nil {
 		fmt.Printf("[%s] [%s] %s\n",
 			color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
 			color.Red.Text("ERROR  "),
-			color.Red.Text("Error read body"),
+			color.Red.Text(fmt.Sprintf("Skip %s: %s", nft_info.token_name, err.Error())),
 		)
 
 		return
 	}
+	nft_info.wallet = wallet
 
-	to_sign := string(body)[3:]
-	to_sign = to_sign[:len(to_sign)-1]
-
-	data, _ := hex.DecodeString(to_sign)
-
-	signature := ed25519.Sign(Config.wallet.privateKey, data)
-	thx["signature"] = map[string]string{
-		"type":       "ed25519_signature",
-		"public_key": Config.wallet.publicKeyStr,
-		"signature":  fmt.Sprintf("0x%x", signature),
+	if !reserved && wallet.balance.available() < amount {
+		fmt.Printf("[%s] [%s] %s\n",
+			color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+			color.Yellow.Text("INFO   "),
+			color.Yellow.Text("Waiting for balance: "+nft_info.token_name),
+		)
 	}
 
-	txn_request, _ = json.Marshal(thx)
-
-	req, _ = http.NewRequest("POST", Config.client.transactions, bytes.NewReader(txn_request))
-	req.Header.Add("Content-Type", "application/json")
-
-	res, err = http.DefaultClient.Do(req)
-	if err != nil {
+	if !reserved && !wallet.balance.reserve(amount, spend_queue_timeout) {
 		fmt.Printf("[%s] [%s] %s\n",
 			color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
 			color.Red.Text("ERROR  "),
-			color.Red.Text("Error send transaction"),
+			color.Red.Text(fmt.Sprintf("Skip %s: need %f Apt, available %f Apt", nft_info.token_name, float64(amount)/100_000_000, float64(wallet.balance.available())/100_000_000)),
 		)
 
 		return
 	}
-	defer res.Body.Close()
 
-	if body, err = ioutil.ReadAll(res.Body); err != nil {
+	if Config.session.round_robin && wallet.name != "" {
 		fmt.Printf("[%s] [%s] %s\n",
 			color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
-			color.Red.Text("ERROR  "),
-			color.Red.Text("Error read body"),
+			color.Yellow.Text("INFO   "),
+			"Buy "+nft_info.token_name+" with wallet "+wallet.name,
 		)
 	}
 
-	switch res.StatusCode {
-	case 202:
-		var response struct {
-			Hash string `json:"hash"`
+	settled := false
+	defer func() {
+		if !settled {
+			wallet.balance.release(amount)
 		}
+	}()
 
-		if json.Unmarshal(body, &response); (err != nil) || (response.Hash == "") {
-			fmt.Printf("[%s] [%s] %s\n",
-				color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
-				color.Red.Text("ERROR  "),
-				color.Red.Text("Transaction error"),
-			)
-
-			return
+	var status int
+	var body []byte
+	var expiration_timestamp_secs int64
+
+	// retry once after resync sequence number with node
+	for attempt := 0; attempt < 2; attempt++ {
+		sequence_number := wallet.sequence.allocate()
+		expiration_timestamp_secs = time.Now().Unix() + 600
+		max_gas_amount := Config.Gas.Max_gas_amount
+		gas_unit_price := Config.gas_unit_price(nft_info.marketplace, nft_info.collection)
+
+		thx := map[string]interface{}{
+			"sender":                    wallet.address_str,
+			"sequence_number":           fmt.Sprintf("%d", sequence_number),
+			"max_gas_amount":            fmt.Sprintf("%d", max_gas_amount),
+			"gas_unit_price":            fmt.Sprintf("%d", gas_unit_price),
+			"expiration_timestamp_secs": fmt.Sprintf("%d", expiration_timestamp_secs),
+			"payload":                   payload,
+			"signature":                 nil,
 		}
 
-		fmt.Printf("[%s] [%s] %s\n",
-			color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
-			color.Yellow.Text("INFO   "),
-			color.Yellow.Text("Transaction send successfully thx: "+response.Hash),
-		)
+		// skip doomed buys before they burn gas
+		if Config.session.simulate && attempt == 0 {
+			var fee_payer *fee_payer_struct
+			if Config.session.fee_payer {
+				fee_payer = &fee_payer_struct{
+					address:       Config.gas_wallet.address,
+					authenticator: Config.gas_wallet.zero_authenticator(),
+				}
+			}
+
+			simulation, err := simulate_transaction(Config, thx, signature_json(wallet.zero_authenticator(), fee_payer))
+
+			switch {
+			case err != nil:
+				fmt.Printf("[%s] [%s] %s\n",
+					color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+					color.Red.Text("ERROR  "),
+					color.Red.Text(err.Error()),
+				)
+
+			case simulation.doomed():
+				fmt.Printf("[%s] [%s] %s\n",
+					color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+					color.Red.Text("ERROR  "),
+					color.Red.Text("Simulation aborted, skip "+nft_info.token_name+": "+simulation.vm_status),
+				)
+
+				wallet.sequence.release(sequence_number)
+				return
 
-		Config.wallet.sequence_number += 1
-		time.Sleep(10000 * time.Millisecond)
+			default:
+				max_gas_amount = simulated_max_gas(simulation.gas_used, max_gas_amount)
+				thx["max_gas_amount"] = fmt.Sprintf("%d", max_gas_amount)
 
-		req, _ := http.NewRequest("GET", Config.client.result+response.Hash, nil)
-		res, err := http.DefaultClient.Do(req)
-		if (err != nil) || (res.StatusCode != 200) {
+				fmt.Printf("[%s] [%s] %s\n",
+					color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+					color.Yellow.Text("INFO   "),
+					fmt.Sprintf("Simulation gas used %d, max gas %d", simulation.gas_used, max_gas_amount),
+				)
+			}
+		}
+
+		raw_transaction := raw_transaction_struct{
+			sender:                    wallet.address,
+			sequence_number:           sequence_number,
+			payload:                   transaction_payload,
+			max_gas_amount:            max_gas_amount,
+			gas_unit_price:            gas_unit_price,
+			expiration_timestamp_secs: uint64(expiration_timestamp_secs),
+			chain_id:                  Config.node().chain_id,
+		}
+
+		signed_transaction, hash, err := sign_transaction(Config, wallet, raw_transaction, thx)
+		if err != nil {
 			fmt.Printf("[%s] [%s] %s\n",
 				color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
 				color.Red.Text("ERROR  "),
-				color.Red.Text("Faild purchased"),
+				color.Red.Text("Error sign transaction: "+err.Error()),
 			)
 
+			wallet.sequence.release(sequence_number)
 			return
 		}
-		defer res.Body.Close()
 
-		var body []byte
-		if body, err = ioutil.ReadAll(res.Body); err != nil {
+		status, body, err = submit_signed(Config, signed_transaction, thx, hash)
+		if err != nil {
 			fmt.Printf("[%s] [%s] %s\n",
 				color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
 				color.Red.Text("ERROR  "),
-				color.Red.Text("Error read response body"),
+				color.Red.Text("Error send transaction: "+err.Error()),
 			)
 
+			// the transaction may have reached a node so the number is not reusable, and if it did not
+			// it leaves a gap parking every later transaction, start over from the on-chain value
+			if err := wallet.sequence.reconcile(true); err != nil {
+				fmt.Printf("[%s] [%s] %s\n",
+					color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+					color.Red.Text("ERROR  "),
+					color.Red.Text(err.Error()),
+				)
+			}
+
 			return
 		}
 
-		var response_hush struct {
-			Success   bool   `json:"success"`
-			Message   string `json:"message"`
-			Vm_status string `json:"vm_status"`
+		if status != 400 {
+			break
 		}
-		json.Unmarshal(body, &response_hush)
 
-		if response_hush.Message != "" {
+		var response struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(body, &response)
+
+		too_old, too_new := sequence_number_error(response.Message)
+		if !too_old && !too_new {
+			// rejected before mempool, number can be reused
+			wallet.sequence.release(sequence_number)
+			break
+		}
+
+		if err := wallet.sequence.reconcile(too_new); err != nil {
+			break
+		}
+	}
+
+	switch status {
+	case 202:
+		var response struct {
+			Hash string `json:"hash"`
+		}
+
+		if err := json.Unmarshal(body, &response); err != nil || response.Hash == "" {
 			fmt.Printf("[%s] [%s] %s\n",
 				color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
 				color.Red.Text("ERROR  "),
-				color.Yellow.Text(response_hush.Message),
+				color.Red.Text("Transaction error"),
 			)
 
 			return
 		}
 
-		if response_hush.Success == true {
-			fmt.Printf("[%s] [%s] %s\n",
-				color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
-				color.Green.Text("SUCCESS"),
-				func() string {
-					switch nft_info.mode {
-					case "sniper":
-						return color.Green.Text("Successfully purchased " + nft_info.token_name + " for " + fmt.Sprintf("%f", nft_info.price/100_000_000))
-					default:
-						return color.Green.Text("Successfully purchased")
-					}
-				}(),
-			)
+		fmt.Printf("[%s] [%s] %s\n",
+			color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+			color.Yellow.Text("INFO   "),
+			color.Yellow.Text("Transaction send successfully thx: "+response.Hash),
+		)
 
-			return
-		} else {
-			fmt.Printf("[%s] [%s] %s\n",
-				color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
-				color.Red.Text("ERROR  "),
-				color.Red.Text("Faild purchased: "+response_hush.Vm_status),
-			)
+		result := wait_transaction(Config, response.Hash, expiration_timestamp_secs)
+		if result.status == txn_committed {
+			wallet.balance.settle(amount)
+			settled = true
+		}
+
+		report_transaction(Config, result, nft_info)
+
+		if result.status == txn_committed {
+			verify_purchases(Config, nft_info, result.hash)
 		}
 
 	case 400:
@@ -942,19 +1248,47 @@ func send_transaction(Config *config_struct, payload payload_struct, nft_info nf
 			color.Red.Text("ERROR  "),
 			color.Red.Text(response.Message),
 		)
-		if response.Message == "Invalid transaction: Type: Validation Code: SEQUENCE_NUMBER_TOO_OLD" {
-			Config.wallet.sequence_number += 1
-		}
 
 	default:
 		fmt.Printf("[%s] [%s] %s\n",
 			color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
 			color.Red.Text("ERROR  "),
-			color.Red.Text("Error send transaction: "+res.Status),
+			color.Red.Text(fmt.Sprintf("Error send transaction: %d", status)),
 		)
 	}
 }
 
+// sign_transaction signs for the sender wallet without encode_submission round trip, the gas wallet signs the same message
+func sign_transaction(Config *config_struct, wallet *wallet_struct, raw_transaction raw_transaction_struct, thx map[string]interface{}) ([]byte, string, error) {
+
+	var fee_payer *fee_payer_struct
+	var data []byte
+	if Config.session.fee_payer {
+		data = raw_transaction.fee_payer_signing_message(Config.gas_wallet.address)
+
+		authenticator, err := Config.gas_wallet.sign(data)
+		if err != nil {
+			return nil, "", errors.New("gas wallet " + err.Error())
+		}
+		fee_payer = &fee_payer_struct{
+			address:       Config.gas_wallet.address,
+			authenticator: authenticator,
+		}
+	} else {
+		data = raw_transaction.signing_message()
+	}
+
+	sender, err := wallet.sign(data)
+	if err != nil {
+		return nil, "", err
+	}
+	thx["signature"] = signature_json(sender, fee_payer)
+
+	signed_transaction := raw_transaction.signed_transaction(sender, fee_payer)
+
+	return signed_transaction, transaction_hash(signed_transaction), nil
+}
+
 /*
 --------------------Settings--------------------
 */
@@ -965,6 +1299,8 @@ func settings(Config *config_struct) {
 
 		menu := climenu.NewButtonMenu("", "Choose action")
 		menu.AddMenuItem("Discord hook", "discord_hook")
+		menu.AddMenuItem("Gas", "gas")
+		menu.AddMenuItem("Network", "network")
 
 		action, escaped := menu.Run()
 		if escaped {
@@ -974,6 +1310,10 @@ func settings(Config *config_struct) {
 		switch action {
 		case "discord_hook":
 			settings_discord_hook(Config)
+		case "gas":
+			settings_gas(Config)
+		case "network":
+			settings_network(Config)
 		}
 	}
 }
@@ -1035,7 +1375,7 @@ func (config *config_struct) load_config() error {
 	}
 
 	// try open config file
-	file, err = os.OpenFile(fmt.Sprintf("%s/config.json", path), os.O_CREATE, os.ModePerm)
+	file, err = os.OpenFile(fmt.Sprintf("%s/config.json", path), os.O_CREATE, 0600)
 	defer file.Close()
 	if err != nil {
 		return err
@@ -1047,9 +1387,11 @@ func (config *config_struct) load_config() error {
 		color.Info.Tips("successfully created config.json")
 
 		config.Node = "https://fullnode.mainnet.aptoslabs.com/v1"
+		config.Network = "mainnet"
+		config.Gas.defaults()
 
 		js, _ := json.MarshalIndent(config, "", "  ")
-		if err = ioutil.WriteFile(fmt.Sprintf("%s/config.json", path), js, os.ModePerm); err != nil {
+		if err = ioutil.WriteFile(fmt.Sprintf("%s/config.json", path), js, 0600); err != nil {
 			return errors.New("error write config struct to file")
 		}
 
@@ -1059,16 +1401,40 @@ func (config *config_struct) load_config() error {
 	// load config to struct
 	json.Unmarshal(byteValue, config)
 
+	// private keys are only kept in the encrypted keystore or on the remote signer
+	if config.Remote_signer.Url != "" {
+		if !config.plaintext_secrets().empty() {
+			return errors.New("private keys are not used with remote_signer, remove them from config.json")
+		}
+	} else if err := config.unlock_keystore(path); err != nil {
+		return err
+	}
+
 	// check node
-	if new_node(config) {
-		return errors.New("error node")
+	if err := new_node(config); err != nil {
+		return fmt.Errorf("error node %s: %s", config.network_name(), err.Error())
+	}
+
+	// gas price refreshed in background
+	config.Gas.defaults()
+	gas_price, err := new_gas_price(config)
+	if err != nil {
+		return fmt.Errorf("error gas estimate %s: %s", config.network_name(), err.Error())
+	}
+	if config.gas_price != nil {
+		config.gas_price.stop()
 	}
+	config.gas_price = gas_price
+	config.gas_price.start(config, 10000*time.Millisecond)
 
 	// check wallet
-	if new_account(config) {
-		return errors.New("wrong private key")
+	if err := new_account(config); err != nil {
+		return err
 	}
 
+	// balance refreshed in background
+	start_balance(config)
+
 	// check hook
 	if config.Discord.Hook == "" {
 		return errors.New("discord hook not found")
@@ -1078,6 +1444,27 @@ func (config *config_struct) load_config() error {
 	return nil
 }
 
+// read_config reads config.json without unlocking keys or connecting to nodes, dir is the config folder
+func read_config() (config_struct, string, error) {
+
+	var config config_struct
+
+	dir, err := filepath.Abs(filepath.Dir(os.Args[0]))
+	if err != nil {
+		return config, "", err
+	}
+
+	data, err := os.ReadFile(fmt.Sprintf("%s/config.json", dir))
+	if err != nil {
+		return config, dir, errors.New("error read config.json")
+	}
+	if err = json.Unmarshal(data, &config); err != nil {
+		return config, dir, errors.New("error decode config.json")
+	}
+
+	return config, dir, nil
+}
+
 func (Config *config_struct) dump_config() error {
 
 	path, err := filepath.Abs(filepath.Dir(os.Args[0]))
@@ -1085,132 +1472,94 @@ func (Config *config_struct) dump_config() error {
 		return err
 	}
 
-	js, _ := json.MarshalIndent(Config, "", "  ")
-	if err = ioutil.WriteFile(fmt.Sprintf("%s/config.json", path), js, os.ModePerm); err != nil {
+	// raw keys stay in the keystore
+	dump := *Config
+	dump.Key = ""
+	dump.Mnemonic = ""
+	dump.Gas_wallet.Key = ""
+	dump.Multisig.Keys = nil
+
+	js, _ := json.MarshalIndent(dump, "", "  ")
+	if err = ioutil.WriteFile(fmt.Sprintf("%s/config.json", path), js, 0600); err != nil {
 		return errors.New("error dump config")
 	}
 
+	// files written by older versions were world readable
+	os.Chmod(fmt.Sprintf("%s/config.json", path), 0600)
+
 	return nil
 }
 
-func new_node(Config *config_struct) bool {
+func new_node(Config *config_struct) error {
 
-	req, _ := http.NewRequest("GET", Config.Node, nil)
-	_, err := http.DefaultClient.Do(req)
+	network := Config.network()
 
+	// chain id of the profile is checked against every node
+	pool, err := new_node_pool(network.Nodes, network.Chain_id)
 	if err != nil {
-		return true
+		return err
 	}
 
-	Config.client = struct {
-		url          string
-		accounts     string
-		encode       string
-		transactions string
-		result       string
-	}{
-		url:          Config.Node,
-		accounts:     Config.Node + "/accounts/",
-		encode:       Config.Node + "/transactions/encode_submission",
-		transactions: Config.Node + "/transactions",
-		result:       Config.Node + "/transactions/by_hash/",
+	// probe nodes in background and fail over to the healthiest
+	if Config.node_pool != nil {
+		Config.node_pool.stop()
 	}
+	Config.node_pool = pool
+	Config.node_pool.switched = func() { update_header_node(Config) }
+	Config.node_pool.start(5000 * time.Millisecond)
 
-	return false
+	return nil
 }
 
-func new_account(Config *config_struct) bool {
+func new_account(Config *config_struct) error {
 
-	switch len(Config.Key) {
-	case 64:
-	case 66:
-		Config.Key = Config.Key[2:]
-	default:
-		return true
+	wallet, gas_wallet, others, err := Config.signer_wallets()
+	if err != nil {
+		return err
 	}
 
-	seed, _ := hex.DecodeString(Config.Key)
-
-	privateKey := ed25519.NewKeyFromSeed(seed[:])
-
-	publicKey := privateKey.Public().(ed25519.PublicKey)
-
-	data := append(publicKey, 0x00)
-	authKey := sha3.Sum256(data)
-
-	Config.wallet = struct {
-		balance         string
-		privateKey      ed25519.PrivateKey
-		publicKey       ed25519.PublicKey
-		address         [32]byte
-		privateKeyStr   string
-		publicKeyStr    string
-		address_str     string
-		sequence_number int
-	}{
-		privateKey:    privateKey,
-		publicKey:     publicKey,
-		address:       authKey,
-		privateKeyStr: fmt.Sprintf("0x%x", privateKey),
-		publicKeyStr:  fmt.Sprintf("0x%x", publicKey),
-		address_str:   fmt.Sprintf("0x%x", authKey),
+	if err = wallet.use_address(Config.node(), Config.Address); err != nil {
+		return err
 	}
 
-	Config.client.accounts += Config.wallet.address_str
+	// gas wallet is optional
+	if gas_wallet != nil {
+		if err = gas_wallet.use_address(Config.node(), Config.Gas_wallet.Address); err != nil {
+			return errors.New("gas wallet " + err.Error())
+		}
+	}
 
 	// get balance
-	req, _ := http.NewRequest("GET", Config.client.accounts+"/resources", nil)
-	req.Header.Add("Content-Type", "application/json")
-	res, err := http.DefaultClient.Do(req)
+	octas, err := fetch_balance(Config.node(), wallet.address_str)
 	if err != nil {
-		return true
-	}
-	defer res.Body.Close()
-
-	var body []byte
-	if body, err = ioutil.ReadAll(res.Body); err != nil {
-		return true
-	}
-
-	type response_balance []struct {
-		Type string `json:"type"`
-		Data struct {
-			Coin struct {
-				Value float64 `json:"value,string"`
-			} `json:"coin"`
-		} `json:"data,omitempty"`
+		return err
 	}
-
-	var response_resources response_balance
-	json.Unmarshal(body, &response_resources)
-
-	Config.wallet.balance = fmt.Sprintf("%f", response_resources[3].Data.Coin.Value/100_000_000)
+	wallet.balance.set(octas)
 
 	// get sequence number
-	req, _ = http.NewRequest("GET", Config.client.accounts, nil)
-	req.Header.Add("Content-Type", "application/json")
-	res, err = http.DefaultClient.Do(req)
-	if err != nil {
-		return true
+	address := wallet.address_str
+	if wallet.sequence, err = new_sequence_manager(func() string {
+		return Config.node().accounts + address
+	}); err != nil {
+		return err
 	}
-	defer res.Body.Close()
-	body, _ = ioutil.ReadAll(res.Body)
 
-	var response struct {
-		Sequence_number string `json:"sequence_number"`
-	}
-	json.Unmarshal(body, &response)
+	// the loaded account replaces the previous one only now, a failure above keeps it usable
+	Config.wallet = wallet
+	Config.gas_wallet = gas_wallet
 
-	Config.wallet.sequence_number, _ = strconv.Atoi(response.Sequence_number)
+	// the other wallets snipe round robin with the account
+	Config.wallets = new_wallet_pool(&Config.wallet, others)
+	Config.wallets.load(Config)
 
-	return false
+	return nil
 }
 
 /*
 --------------------CLI--------------------
 */
 
-func logo(Balance string) {
+func logo(Balance string, Node string) {
 
 	color.Magentaln(`by https://github.com/mkdr4
   __    __     ______     ______     ______     __  __     ______     __  __
@@ -1218,7 +1567,8 @@ func logo(Balance string) {
  \ \ \-./\ \  \ \  __\   \ \  __<   \ \ \____  \ \ \_\ \  \ \  __<   \ \____ \
   \ \_\ \ \_\  \ \_____\  \ \_\ \_\  \ \_____\  \ \_____\  \ \_\ \_\  \/\_____\
    \/_/  \/_/   \/_____/   \/_/ /_/   \/_____/   \/_____/   \/_/ /_/   \/` + Version + `/		
-Balance: ` + Balance)
+Balance: ` + Balance + `
+Node: ` + Node)
 }
 
 func Clear(count_line int, info any, type_info any) {
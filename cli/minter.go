@@ -0,0 +1,414 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gookit/color"
+	term "github.com/nsf/termbox-go"
+	"github.com/paulrademacher/climenu"
+)
+
+/*
+--------------------Minter--------------------
+*/
+
+// pre-signed mints stay valid this long after the start
+const mint_expiration = 120 * time.Second
+
+type mint_struct struct {
+	nft_info        nft_info
+	thx             map[string]interface{}
+	signed          []byte
+	hash            string
+	sequence_number uint64
+	expiration      int64 // ledger clock
+	amount          uint64
+}
+
+func aptos_minter(Config *config_struct) {
+
+	Clear(4, "action > aptos sniper > minter", "info")
+
+	payload, ok := ask_entry_function(Config)
+	if !ok {
+		return
+	}
+
+	transaction_payload, err := payload.transaction_payload()
+	if err != nil {
+		color.Warn.Tips("error encode payload: " + err.Error() + ". Press enter for back.")
+		fmt.Scanln()
+		return
+	}
+
+	var quantity uint64
+	for {
+		quantity, err = strconv.ParseUint(climenu.GetText("Quantity", "eg: 3"), 10, 64)
+		Clear(1, nil, nil)
+		if err == nil && quantity > 0 {
+			fmt.Printf("%s %d\n", color.Magenta.Text("Quantity  "), quantity)
+			break
+		}
+	}
+
+	var mint_price float64
+	for {
+		mint_price, err = strconv.ParseFloat(climenu.GetText("Mint price", "eg: 0.5"), 64)
+		Clear(1, nil, nil)
+		if err == nil && mint_price >= 0 {
+			fmt.Printf("%s %f\n", color.Magenta.Text("Mint price"), mint_price)
+			mint_price = mint_price * 100_000_000
+			break
+		}
+	}
+
+	var start time.Time
+	for {
+		start, err = parse_start_time(climenu.GetText("Start time", "eg: 2026-01-31 15:00:00 (UTC), unix seconds or +5m"), Config.node())
+		Clear(1, nil, nil)
+		if err == nil {
+			fmt.Printf("%s %s\n", color.Magenta.Text("Start     "), start.UTC().Format("2006-01-02 15:04:05 UTC"))
+			break
+		}
+	}
+
+	if !ask_fee_payer(Config) {
+		return
+	}
+
+	// create new workspace for minter in terminal
+	term.Init()
+	defer term.Close()
+
+	logo(Config.wallet.balance.String(), Config.node().url+" ["+Config.network_name()+"]")
+	pin_header(Config)
+	Clear(0, "action > aptos sniper > minter", "info")
+	fmt.Printf("%s %s\n", color.Magenta.Text("Function  "), payload.Function)
+	fmt.Printf("%s %d\n", color.Magenta.Text("Quantity  "), quantity)
+	fmt.Printf("%s %f\n", color.Magenta.Text("Mint price"), mint_price/100_000_000)
+	fmt.Printf("%s %s\n", color.Magenta.Text("Start     "), start.UTC().Format("2006-01-02 15:04:05 UTC"))
+	if Config.session.fee_payer {
+		fmt.Printf("%s %s\n", color.Magenta.Text("Gas wallet"), Config.gas_wallet.address_str)
+	}
+
+	minter := func(escaped *bool) {
+
+		mints := presign_mints(Config, payload, transaction_payload, quantity, uint64(mint_price), start)
+		if len(mints) == 0 {
+			return
+		}
+
+		if !wait_start(Config, start, escaped) {
+			cancel_mints(Config, mints)
+			return
+		}
+
+		fire_mints(Config, mints)
+
+		fmt.Printf("[%s] [%s] %s\n",
+			color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+			color.Green.Text("INFO   "),
+			"Minter finished, press Esc for back",
+		)
+	}
+
+	var escaped bool
+	go minter(&escaped)
+
+	for {
+		switch ev := term.PollEvent(); ev.Type {
+		case term.EventKey:
+			switch ev.Key {
+			case term.KeyEsc:
+				escaped = true
+
+				time.Sleep(2000 * time.Millisecond)
+
+				fmt.Printf("[%s] [%s] %s\n",
+					color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+					color.Green.Text("INFO   "),
+					"Minter stopped",
+				)
+
+				unpin_header(Config)
+				term.Close()
+				return
+			}
+		}
+	}
+}
+
+// parse_start_time takes a UTC date, unix seconds or a duration from now on the ledger clock
+func parse_start_time(input string, client client_struct) (time.Time, error) {
+
+	input = strings.TrimSpace(input)
+
+	if strings.HasPrefix(input, "+") {
+		duration, err := time.ParseDuration(input[1:])
+		if err != nil {
+			return time.Time{}, err
+		}
+		offset, err := ledger_offset(client)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		return time.Now().Add(offset + duration).Truncate(time.Second), nil
+	}
+
+	if seconds, err := strconv.ParseInt(input, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+
+	return time.ParseInLocation("2006-01-02 15:04:05", input, time.UTC)
+}
+
+// ledger_offset is how far the ledger clock of the node runs ahead of the local clock
+func ledger_offset(client client_struct) (time.Duration, error) {
+
+	var best node_struct
+	var local time.Time
+
+	// the fastest of a few samples has the smallest round trip error
+	for sample := 0; sample < 3; sample++ {
+		start := time.Now()
+		node := probe_node(node_struct{client: client})
+		if node.reason != "" {
+			continue
+		}
+		if best.ledger_timestamp == 0 || node.latency < best.latency {
+			best = node
+			local = start.Add(node.latency / 2)
+		}
+	}
+
+	if best.ledger_timestamp == 0 {
+		return 0, errors.New("ledger info: node unavailable")
+	}
+
+	return time.UnixMicro(int64(best.ledger_timestamp)).Sub(local), nil
+}
+
+// presign_mints reserves balance and sequence numbers and signs every mint before the start
+func presign_mints(Config *config_struct, payload payload_struct, transaction_payload transaction_payload_struct, quantity uint64, price uint64, start time.Time) []mint_struct {
+
+	var mints []mint_struct
+
+	max_gas_amount := Config.Gas.Max_gas_amount
+	gas_unit_price := Config.gas_unit_price("minter", payload.Function)
+	expiration_timestamp_secs := start.Add(mint_expiration).Unix()
+
+	for i := uint64(0); i < quantity; i++ {
+
+		amount := price
+		if !Config.session.fee_payer {
+			amount += max_gas_amount * gas_unit_price
+		}
+
+		if !Config.wallet.balance.reserve(amount, 0) {
+			fmt.Printf("[%s] [%s] %s\n",
+				color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+				color.Red.Text("ERROR  "),
+				color.Red.Text(fmt.Sprintf("Balance covers %d of %d mints", i, quantity)),
+			)
+
+			break
+		}
+
+		sequence_number := Config.wallet.sequence.allocate()
+
+		thx := map[string]interface{}{
+			"sender":                    Config.wallet.address_str,
+			"sequence_number":           fmt.Sprintf("%d", sequence_number),
+			"max_gas_amount":            fmt.Sprintf("%d", max_gas_amount),
+			"gas_unit_price":            fmt.Sprintf("%d", gas_unit_price),
+			"expiration_timestamp_secs": fmt.Sprintf("%d", expiration_timestamp_secs),
+			"payload":                   payload,
+			"signature":                 nil,
+		}
+
+		raw_transaction := raw_transaction_struct{
+			sender:                    Config.wallet.address,
+			sequence_number:           sequence_number,
+			payload:                   transaction_payload,
+			max_gas_amount:            max_gas_amount,
+			gas_unit_price:            gas_unit_price,
+			expiration_timestamp_secs: uint64(expiration_timestamp_secs),
+			chain_id:                  Config.node().chain_id,
+		}
+
+		signed_transaction, hash, err := sign_transaction(Config, &Config.wallet, raw_transaction, thx)
+		if err != nil {
+			fmt.Printf("[%s] [%s] %s\n",
+				color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+				color.Red.Text("ERROR  "),
+				color.Red.Text(fmt.Sprintf("Error sign mint #%d: %s", i+1, err.Error())),
+			)
+
+			Config.wallet.sequence.release(sequence_number)
+			Config.wallet.balance.release(amount)
+			break
+		}
+
+		mints = append(mints, mint_struct{
+			nft_info: nft_info{
+				token_name:  fmt.Sprintf("mint #%d", i+1),
+				price:       float64(price),
+				mode:        "minter",
+				marketplace: "minter",
+				collection:  payload.Function,
+			},
+			thx:             thx,
+			signed:          signed_transaction,
+			hash:            hash,
+			sequence_number: sequence_number,
+			expiration:      expiration_timestamp_secs,
+			amount:          amount,
+		})
+	}
+
+	if len(mints) > 0 {
+		fmt.Printf("[%s] [%s] %s\n",
+			color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+			color.Yellow.Text("INFO   "),
+			fmt.Sprintf("Signed %d mints, sequence numbers %d..%d", len(mints), mints[0].sequence_number, mints[len(mints)-1].sequence_number),
+		)
+	}
+
+	return mints
+}
+
+// wait_start sleeps until the ledger clock reaches start, resyncing the offset on the way
+func wait_start(Config *config_struct, start time.Time, escaped *bool) bool {
+
+	offset, err := ledger_offset(Config.node())
+	if err != nil {
+		fmt.Printf("[%s] [%s] %s\n",
+			color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+			color.Red.Text("ERROR  "),
+			color.Red.Text(err.Error()+", using local clock"),
+		)
+	}
+
+	fmt.Printf("[%s] [%s] %s\n",
+		color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+		color.Yellow.Text("INFO   "),
+		fmt.Sprintf("Ledger clock offset %s, start in %s", offset.Round(time.Millisecond), time.Until(start.Add(-offset)).Round(time.Second)),
+	)
+
+	synced := time.Now()
+
+	for !*escaped {
+		remaining := time.Until(start.Add(-offset))
+		if remaining <= 0 {
+			return true
+		}
+
+		// resync every 30 seconds and once more right before the start
+		if time.Since(synced) > 30*time.Second || (remaining < 5*time.Second && time.Since(synced) > 5*time.Second) {
+			if resynced, err := ledger_offset(Config.node()); err == nil {
+				offset = resynced
+			}
+			synced = time.Now()
+			continue
+		}
+
+		switch {
+		case remaining > time.Second:
+			// short naps keep escape responsive
+			nap := remaining - time.Second
+			if nap > 500*time.Millisecond {
+				nap = 500 * time.Millisecond
+			}
+			time.Sleep(nap)
+		case remaining > 10*time.Millisecond:
+			time.Sleep(remaining - 10*time.Millisecond)
+		default:
+			// spin the last milliseconds
+		}
+	}
+
+	return false
+}
+
+// fire_mints submits every pre-signed mint at once and waits for the results
+func fire_mints(Config *config_struct, mints []mint_struct) {
+
+	fmt.Printf("[%s] [%s] %s\n",
+		color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+		color.Yellow.Text("INFO   "),
+		fmt.Sprintf("Start minting %d transactions", len(mints)),
+	)
+
+	var wait sync.WaitGroup
+	var mutex sync.Mutex
+	rejected := false
+
+	offset, _ := ledger_offset(Config.node())
+
+	for _, mint := range mints {
+		wait.Add(1)
+
+		go func(mint mint_struct) {
+			defer wait.Done()
+
+			status, body, err := submit_signed(Config, mint.signed, mint.thx, mint.hash)
+			if err != nil || status != 202 {
+				var response struct {
+					Message string `json:"message"`
+				}
+				json.Unmarshal(body, &response)
+
+				fmt.Printf("[%s] [%s] %s\n",
+					color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+					color.Red.Text("ERROR  "),
+					color.Red.Text(fmt.Sprintf("%s rejected: %d %s", mint.nft_info.token_name, status, response.Message)),
+				)
+
+				Config.wallet.balance.release(mint.amount)
+
+				mutex.Lock()
+				rejected = true
+				mutex.Unlock()
+				return
+			}
+
+			fmt.Printf("[%s] [%s] %s\n",
+				color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+				color.Yellow.Text("INFO   "),
+				color.Yellow.Text("Transaction send successfully thx: "+mint.hash),
+			)
+
+			result := wait_transaction(Config, mint.hash, mint.expiration-int64(offset/time.Second))
+
+			if result.status == txn_committed {
+				Config.wallet.balance.settle(mint.amount)
+			} else {
+				Config.wallet.balance.release(mint.amount)
+			}
+
+			report_transaction(Config, result, mint.nft_info)
+		}(mint)
+	}
+
+	wait.Wait()
+
+	// numbers after a rejected mint are stuck, start over from the chain
+	if rejected {
+		Config.wallet.sequence.reconcile(true)
+	}
+}
+
+// cancel_mints gives back what presign_mints reserved
+func cancel_mints(Config *config_struct, mints []mint_struct) {
+	for i := len(mints) - 1; i >= 0; i-- {
+		Config.wallet.sequence.release(mints[i].sequence_number)
+		Config.wallet.balance.release(mints[i].amount)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	_ "embed"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+/*
+--------------------Mnemonic--------------------
+*/
+
+//go:embed wordlist/english.txt
+var bip39_english string
+
+var bip39_words = func() map[string]int {
+	words := map[string]int{}
+	for i, word := range strings.Fields(bip39_english) {
+		words[word] = i
+	}
+	return words
+}()
+
+// new_mnemonic_wallet derives the key of account index on m/44'/637'/index'/0'/0' like Petra and Martian
+func new_mnemonic_wallet(mnemonic string, index uint32) (wallet_struct, error) {
+
+	seed, err := mnemonic_seed(mnemonic)
+	if err != nil {
+		return wallet_struct{}, err
+	}
+
+	key := slip10_ed25519(seed, []uint32{44, 637, index, 0, 0})
+
+	return new_wallet(fmt.Sprintf("%x", key))
+}
+
+// mnemonic_seed checks the BIP-39 words and checksum, then stretches the sentence into the seed
+func mnemonic_seed(mnemonic string) ([]byte, error) {
+
+	words := strings.Fields(strings.ToLower(mnemonic))
+	switch len(words) {
+	case 12, 15, 18, 21, 24:
+	default:
+		return nil, fmt.Errorf("mnemonic: %d words, expected 12, 15, 18, 21 or 24", len(words))
+	}
+
+	// 11 bits per word, entropy followed by one checksum bit per 32 bits of entropy
+	bits := make([]byte, 0, len(words)*11)
+	for i, word := range words {
+		n, ok := bip39_words[word]
+		if !ok {
+			return nil, fmt.Errorf("mnemonic: word %d %q is not in the BIP-39 list", i+1, word)
+		}
+		for bit := 10; bit >= 0; bit-- {
+			bits = append(bits, byte(n>>bit)&1)
+		}
+	}
+
+	checksum_bits := len(bits) / 33
+	entropy := make([]byte, (len(bits)-checksum_bits)/8)
+	for i := range entropy {
+		for bit := 0; bit < 8; bit++ {
+			entropy[i] = entropy[i]<<1 | bits[i*8+bit]
+		}
+	}
+
+	hash := sha256.Sum256(entropy)
+	for bit := 0; bit < checksum_bits; bit++ {
+		if hash[0]>>(7-bit)&1 != bits[len(entropy)*8+bit] {
+			return nil, errors.New("mnemonic: wrong checksum, check the words and their order")
+		}
+	}
+
+	return pbkdf2.Key([]byte(strings.Join(words, " ")), []byte("mnemonic"), 2048, 64, sha512.New), nil
+}
+
+// slip10_ed25519 derives the private key seed on a path, ed25519 only has hardened children
+func slip10_ed25519(seed []byte, path []uint32) []byte {
+
+	mac := hmac.New(sha512.New, []byte("ed25519 seed"))
+	mac.Write(seed)
+	node := mac.Sum(nil)
+
+	for _, index := range path {
+		data := append([]byte{0x00}, node[:32]...)
+		data = binary.BigEndian.AppendUint32(data, index|0x80000000)
+
+		mac = hmac.New(sha512.New, node[32:])
+		mac.Write(data)
+		node = mac.Sum(nil)
+	}
+
+	return node[:32]
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const test_mnemonic = "shoot island position soft burden budget tooth cruel issue economy destroy above"
+
+func TestMnemonicWallet(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		mnemonic string
+		index    uint32
+		address  string
+	}{
+		// address shown by the official wallets for this phrase
+		{"12 words", test_mnemonic, 0, "0x07968dab936c1bad187c60ce4082f307d030d780e91e694ae03aef16aba73f30"},
+		{"second account", test_mnemonic, 1, "0x783135e8b00430253a22ba041d860c373d7a1501ccf7ac2d1ad37a8ed2775aee"},
+		{"spacing and case", "  SHOOT island position soft burden budget\ttooth cruel issue economy destroy above ", 0, "0x07968dab936c1bad187c60ce4082f307d030d780e91e694ae03aef16aba73f30"},
+		{"24 words", strings.Repeat("abandon ", 23) + "art", 0, "0x226b5b5c15d19946b1fd26c3e4ad36833fd8c9c11c612b1327e608f824a5f2d4"},
+	}
+
+	for _, test := range tests {
+		wallet, err := new_mnemonic_wallet(test.mnemonic, test.index)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if wallet.address_str != test.address {
+			t.Errorf("%s: address %s, want %s", test.name, wallet.address_str, test.address)
+		}
+	}
+}
+
+func TestMnemonicInvalid(t *testing.T) {
+
+	tests := map[string]string{
+		"bad checksum":    strings.TrimSpace(strings.Repeat("abandon ", 12)),
+		"swapped words":   "island shoot position soft burden budget tooth cruel issue economy destroy above",
+		"unknown word":    "shoot island position soft burden budget tooth cruel issue economy destroy aptos",
+		"11 words":        "shoot island position soft burden budget tooth cruel issue economy destroy",
+		"24 bad checksum": strings.Repeat("abandon ", 23) + "abandon",
+	}
+
+	for name, mnemonic := range tests {
+		if _, err := new_mnemonic_wallet(mnemonic, 0); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
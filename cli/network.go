@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gookit/color"
+	"github.com/paulrademacher/climenu"
+)
+
+/*
+--------------------Network--------------------
+*/
+type contracts_struct struct {
+	Topaz    string `json:"topaz"`
+	Bluemove string `json:"bluemove"`
+	// full function ids buying token v2 listing objects
+	Topaz_v2    string `json:"topaz_v2"`
+	Bluemove_v2 string `json:"bluemove_v2"`
+}
+
+// marketplace api base urls, listings and collections are read from them
+type apis_struct struct {
+	Topaz    string `json:"topaz"`
+	Bluemove string `json:"bluemove"`
+}
+
+type network_profile_struct struct {
+	Nodes     []string         `json:"nodes"`
+	Chain_id  uint8            `json:"chain_id"` // 0 skips the check, devnet changes it on every reset
+	Contracts contracts_struct `json:"contracts"`
+	Apis      apis_struct      `json:"apis"`
+}
+
+var network_names = []string{"mainnet", "testnet", "devnet", "local"}
+
+var network_defaults = map[string]network_profile_struct{
+	"mainnet": {
+		Nodes:    []string{"https://fullnode.mainnet.aptoslabs.com/v1"},
+		Chain_id: 1,
+		Contracts: contracts_struct{
+			Topaz:    "0x2c7bccf7b31baf770fdbcc768d9e9cb3d87805e255355df5db32ac9a669010a2",
+			Bluemove: "0xd1fd99c1944b84d1670a2536417e997864ad12303d19eac725891691b04d614e",
+		},
+		Apis: apis_struct{
+			Topaz:    "https://api-v1.topaz.so/api",
+			Bluemove: "https://aptos-mainnet-api.bluemove.net/api",
+		},
+	},
+	"testnet": {
+		Nodes:    []string{"https://fullnode.testnet.aptoslabs.com/v1"},
+		Chain_id: 2,
+	},
+	"devnet": {
+		Nodes: []string{"https://fullnode.devnet.aptoslabs.com/v1"},
+	},
+	"local": {
+		Nodes:    []string{"http://127.0.0.1:8080/v1"},
+		Chain_id: 4,
+	},
+}
+
+func (Config *config_struct) network_name() string {
+	if Config.Network == "" {
+		return "mainnet"
+	}
+
+	return Config.Network
+}
+
+// network merges the profile from config.json over the built in defaults
+func (Config *config_struct) network() network_profile_struct {
+
+	name := Config.network_name()
+	profile := network_defaults[name]
+
+	override, ok := Config.Networks[name]
+	if ok {
+		if len(override.Nodes) > 0 {
+			profile.Nodes = override.Nodes
+		}
+		if override.Chain_id != 0 {
+			profile.Chain_id = override.Chain_id
+		}
+		if override.Contracts.Topaz != "" {
+			profile.Contracts.Topaz = override.Contracts.Topaz
+		}
+		if override.Contracts.Bluemove != "" {
+			profile.Contracts.Bluemove = override.Contracts.Bluemove
+		}
+		if override.Contracts.Topaz_v2 != "" {
+			profile.Contracts.Topaz_v2 = override.Contracts.Topaz_v2
+		}
+		if override.Contracts.Bluemove_v2 != "" {
+			profile.Contracts.Bluemove_v2 = override.Contracts.Bluemove_v2
+		}
+		if override.Apis.Topaz != "" {
+			profile.Apis.Topaz = strings.TrimSuffix(override.Apis.Topaz, "/")
+		}
+		if override.Apis.Bluemove != "" {
+			profile.Apis.Bluemove = strings.TrimSuffix(override.Apis.Bluemove, "/")
+		}
+	}
+
+	// aptos_node_url and aptos_broadcast_node_urls keep working for mainnet
+	if name == "mainnet" && !(ok && len(override.Nodes) > 0) && Config.Node != "" {
+		profile.Nodes = append([]string{Config.Node}, Config.Broadcast...)
+	}
+
+	return profile
+}
+
+func settings_network(Config *config_struct) {
+
+	Clear(4, "action > settings > network", "info")
+
+	menu := climenu.NewButtonMenu("", "Choose network ["+Config.network_name()+"]")
+	for _, name := range network_names {
+		menu.AddMenuItem(name, name)
+	}
+
+	name, escaped := menu.Run()
+	Clear(6, nil, nil)
+	if escaped || name == Config.network_name() {
+		return
+	}
+
+	previous := Config.Network
+	Config.Network = name
+
+	// reconnect wallet on the new network, back to the previous one on error
+	if err := new_node(Config); err != nil {
+		Config.Network = previous
+		fmt.Printf("%s %s\n", color.Red.Text("Network"), err.Error())
+		return
+	}
+	if err := new_account(Config); err != nil {
+		fmt.Printf("%s %s\n", color.Red.Text("Network"), "error load wallet on "+name+": "+err.Error())
+
+		// the wallet may be half loaded when the previous network fails too, a restart is needed
+		Config.Network = previous
+		if err := new_node(Config); err != nil {
+			fmt.Printf("%s %s\n", color.Red.Text("Network"), "error reconnect "+Config.network_name()+", restart the sniper: "+err.Error())
+			return
+		}
+		if err := new_account(Config); err != nil {
+			fmt.Printf("%s %s\n", color.Red.Text("Network"), "error reload wallet on "+Config.network_name()+", restart the sniper: "+err.Error())
+		}
+		return
+	}
+
+	if err := Config.dump_config(); err != nil {
+	}
+}
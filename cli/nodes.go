@@ -0,0 +1,273 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gookit/color"
+)
+
+/*
+--------------------Node pool--------------------
+*/
+type client_struct struct {
+	url          string //https://fullnode.mainnet.aptoslabs.com/v1
+	accounts     string //https://fullnode.mainnet.aptoslabs.com/v1/accounts/
+	transactions string //https://fullnode.mainnet.aptoslabs.com/v1/transactions
+	result       string //https://fullnode.mainnet.aptoslabs.com/v1/transactions/by_hash/<<HASH>>
+	wait         string //https://fullnode.mainnet.aptoslabs.com/v1/transactions/wait_by_hash/<<HASH>>
+	simulate     string //https://fullnode.mainnet.aptoslabs.com/v1/transactions/simulate
+	gas          string //https://fullnode.mainnet.aptoslabs.com/v1/estimate_gas_price
+	chain_id     uint8
+}
+
+type node_struct struct {
+	client           client_struct
+	healthy          bool
+	reason           string
+	chain_id         uint8
+	ledger_version   uint64
+	ledger_timestamp uint64 // microseconds
+	latency          time.Duration
+}
+
+type node_pool_struct struct {
+	mutex    sync.RWMutex
+	nodes    []*node_struct
+	active   *node_struct
+	chain_id uint8 // 0 takes the chain id of the first node
+	done     chan struct{}
+	switched func() // called outside the lock when the active node changes
+}
+
+// a node whose ledger is this far behind the freshest one is not used
+const node_max_lag = 10 * time.Second
+
+var probe_client = &http.Client{Timeout: 5 * time.Second}
+
+// node_client is for every other node request, a stalled node must not hold a goroutine forever
+var node_client = &http.Client{Timeout: 10 * time.Second}
+
+func new_client(url string, chain_id uint8) client_struct {
+	url = strings.TrimSuffix(url, "/")
+
+	return client_struct{
+		url:          url,
+		accounts:     url + "/accounts/",
+		transactions: url + "/transactions",
+		result:       url + "/transactions/by_hash/",
+		wait:         url + "/transactions/wait_by_hash/",
+		simulate:     url + "/transactions/simulate",
+		gas:          url + "/estimate_gas_price",
+		chain_id:     chain_id,
+	}
+}
+
+func new_node_pool(urls []string, chain_id uint8) (*node_pool_struct, error) {
+
+	pool := &node_pool_struct{chain_id: chain_id, done: make(chan struct{})}
+
+	seen := map[string]bool{}
+	for _, url := range urls {
+		url = strings.TrimSuffix(strings.TrimSpace(url), "/")
+		if url == "" || seen[url] {
+			continue
+		}
+		seen[url] = true
+		pool.nodes = append(pool.nodes, &node_struct{client: new_client(url, chain_id)})
+	}
+
+	if len(pool.nodes) == 0 {
+		return nil, errors.New("node pool: no node configured")
+	}
+
+	pool.probe()
+
+	if pool.active == nil {
+		var reasons []string
+		for _, node := range pool.nodes {
+			reasons = append(reasons, node.client.url+": "+node.reason)
+		}
+		return nil, errors.New("node pool: no healthy node (" + strings.Join(reasons, "; ") + ")")
+	}
+
+	return pool, nil
+}
+
+// start probes every node in background
+func (pool *node_pool_struct) start(interval time.Duration) {
+	go func() {
+		for {
+			select {
+			case <-pool.done:
+				return
+			case <-time.After(interval):
+				if pool.probe() && pool.switched != nil {
+					pool.switched()
+				}
+			}
+		}
+	}()
+}
+
+func (pool *node_pool_struct) stop() {
+	close(pool.done)
+}
+
+// probe checks every node and reports whether the active one changed
+func (pool *node_pool_struct) probe() bool {
+
+	var wg sync.WaitGroup
+	results := make([]node_struct, len(pool.nodes))
+
+	for i, node := range pool.nodes {
+		wg.Add(1)
+		go func(i int, node node_struct) {
+			defer wg.Done()
+			results[i] = probe_node(node)
+		}(i, *node)
+	}
+	wg.Wait()
+
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	// without an expected chain id the first responding node is the reference
+	if pool.chain_id == 0 {
+		for _, result := range results {
+			if result.reason == "" {
+				pool.chain_id = result.chain_id
+				break
+			}
+		}
+	}
+
+	var freshest uint64
+	for _, result := range results {
+		if result.reason == "" && result.chain_id == pool.chain_id && result.ledger_timestamp > freshest {
+			freshest = result.ledger_timestamp
+		}
+	}
+
+	for i, result := range results {
+		result.healthy = result.reason == ""
+		switch {
+		case !result.healthy:
+		case result.chain_id != pool.chain_id:
+			result.healthy = false
+			result.reason = fmt.Sprintf("chain id %d, expected %d", result.chain_id, pool.chain_id)
+		case time.Duration(freshest-result.ledger_timestamp)*time.Microsecond > node_max_lag:
+			result.healthy = false
+			result.reason = fmt.Sprintf("ledger lag %s", time.Duration(freshest-result.ledger_timestamp)*time.Microsecond)
+		}
+		result.client.chain_id = pool.chain_id
+
+		*pool.nodes[i] = result
+	}
+
+	previous := pool.active
+	pool.active = pool.best()
+
+	if previous != nil && pool.active != nil && previous != pool.active && !previous.healthy {
+		fmt.Printf("[%s] [%s] %s\n",
+			color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+			color.Yellow.Text("INFO   "),
+			color.Yellow.Text(fmt.Sprintf("Node failover %s -> %s (%s)", previous.client.url, pool.active.client.url, previous.reason)),
+		)
+	}
+
+	return previous != pool.active
+}
+
+// best keeps the active node while healthy unless another one is much faster
+func (pool *node_pool_struct) best() *node_struct {
+
+	var best *node_struct
+	for _, node := range pool.nodes {
+		if node.healthy && (best == nil || node.latency < best.latency) {
+			best = node
+		}
+	}
+
+	if best == nil {
+		// nothing healthy, stay on the current node
+		return pool.active
+	}
+
+	if pool.active != nil && pool.active.healthy && pool.active.latency <= best.latency*3/2 {
+		return pool.active
+	}
+
+	return best
+}
+
+func probe_node(node node_struct) node_struct {
+
+	node.reason = ""
+	start := time.Now()
+
+	req, _ := http.NewRequest("GET", node.client.url, nil)
+	res, err := probe_client.Do(req)
+	if err != nil {
+		node.reason = "request error"
+		return node
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	node.latency = time.Since(start)
+	if err != nil || res.StatusCode != 200 {
+		node.reason = "status " + res.Status
+		return node
+	}
+
+	var response struct {
+		Chain_id         uint8  `json:"chain_id"`
+		Ledger_version   string `json:"ledger_version"`
+		Ledger_timestamp string `json:"ledger_timestamp"`
+	}
+	if err = json.Unmarshal(body, &response); err != nil {
+		node.reason = "error decode ledger info"
+		return node
+	}
+
+	node.chain_id = response.Chain_id
+	node.ledger_version, _ = strconv.ParseUint(response.Ledger_version, 10, 64)
+	node.ledger_timestamp, _ = strconv.ParseUint(response.Ledger_timestamp, 10, 64)
+
+	return node
+}
+
+// node is the client of the active node in the pool
+func (Config *config_struct) node() client_struct {
+	return Config.node_pool.client()
+}
+
+func (pool *node_pool_struct) client() client_struct {
+	pool.mutex.RLock()
+	defer pool.mutex.RUnlock()
+
+	return pool.active.client
+}
+
+// healthy returns every healthy node, active first
+func (pool *node_pool_struct) healthy() []client_struct {
+	pool.mutex.RLock()
+	defer pool.mutex.RUnlock()
+
+	clients := []client_struct{pool.active.client}
+	for _, node := range pool.nodes {
+		if node.healthy && node != pool.active {
+			clients = append(clients, node.client)
+		}
+	}
+
+	return clients
+}
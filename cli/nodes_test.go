@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fake_ledger_struct struct {
+	mutex     sync.Mutex
+	chain_id  uint8
+	lag       time.Duration // behind the other nodes
+	delay     time.Duration // probe latency
+	status    int           // 0 answers 200
+	timestamp uint64
+}
+
+func (ledger *fake_ledger_struct) set(chain_id uint8, lag time.Duration, status int) {
+	ledger.mutex.Lock()
+	defer ledger.mutex.Unlock()
+
+	ledger.chain_id, ledger.lag, ledger.status = chain_id, lag, status
+}
+
+// fake_ledger_node answers the ledger info probed on /v1
+func fake_ledger_node(t *testing.T, chain_id uint8, lag time.Duration) (*httptest.Server, *fake_ledger_struct) {
+	t.Helper()
+
+	ledger := &fake_ledger_struct{chain_id: chain_id, lag: lag, timestamp: uint64(time.Now().UnixMicro())}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ledger.mutex.Lock()
+		defer ledger.mutex.Unlock()
+
+		time.Sleep(ledger.delay)
+		if r.URL.Path != "/v1" {
+			http.NotFound(w, r)
+			return
+		}
+		if ledger.status != 0 {
+			w.WriteHeader(ledger.status)
+			return
+		}
+
+		w.Write([]byte(fmt.Sprintf(`{"chain_id":%d,"epoch":"1","ledger_version":"1000","ledger_timestamp":"%d","node_role":"full_node"}`,
+			ledger.chain_id, ledger.timestamp-uint64(ledger.lag/time.Microsecond))))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, ledger
+}
+
+// dead_node is an url nothing listens on
+func dead_node(t *testing.T) string {
+	t.Helper()
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	return server.URL + "/v1"
+}
+
+func pool_node(pool *node_pool_struct, url string) *node_struct {
+	for _, node := range pool.nodes {
+		if node.client.url == url {
+			return node
+		}
+	}
+
+	return nil
+}
+
+func TestNodePoolProbe(t *testing.T) {
+
+	lagging, _ := fake_ledger_node(t, 1, time.Minute)
+	healthy, _ := fake_ledger_node(t, 1, 0)
+	dead := dead_node(t)
+
+	// duplicated and empty urls are dropped
+	pool, err := new_node_pool([]string{lagging.URL + "/v1", dead, "", healthy.URL + "/v1/", healthy.URL + "/v1"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pool.nodes) != 3 {
+		t.Fatalf("%d nodes, want 3", len(pool.nodes))
+	}
+
+	if client := pool.client(); client.url != healthy.URL+"/v1" || client.chain_id != 1 {
+		t.Errorf("active %s chain %d, want the healthy node", client.url, client.chain_id)
+	}
+
+	if node := pool_node(pool, lagging.URL+"/v1"); node.healthy || !strings.HasPrefix(node.reason, "ledger lag") {
+		t.Errorf("lagging node healthy %t %q", node.healthy, node.reason)
+	}
+	if node := pool_node(pool, dead); node.healthy || node.reason != "request error" {
+		t.Errorf("dead node healthy %t %q", node.healthy, node.reason)
+	}
+
+	if healthy := pool.healthy(); len(healthy) != 1 || healthy[0].url != pool.client().url {
+		t.Errorf("healthy %+v, want the active node only", healthy)
+	}
+
+	// nothing healthy fails with every reason
+	_, err = new_node_pool([]string{dead}, 1)
+	if err == nil || !strings.Contains(err.Error(), dead+": request error") {
+		t.Errorf("error %v, want the dead node reason", err)
+	}
+}
+
+func TestNodePoolBest(t *testing.T) {
+
+	active := &node_struct{healthy: true, latency: 100 * time.Millisecond}
+	close_by := &node_struct{healthy: true, latency: 80 * time.Millisecond}
+	fast := &node_struct{healthy: true, latency: 50 * time.Millisecond}
+	down := &node_struct{latency: time.Millisecond}
+
+	tests := []struct {
+		name   string
+		nodes  []*node_struct
+		active *node_struct
+		best   *node_struct
+	}{
+		{"fastest healthy", []*node_struct{active, close_by, down}, nil, close_by},
+		// switching is not worth it for a slightly faster node
+		{"keep active", []*node_struct{active, close_by, down}, active, active},
+		{"much faster", []*node_struct{active, fast, down}, active, fast},
+		{"active down", []*node_struct{down, active}, down, active},
+		{"nothing healthy", []*node_struct{down}, down, down},
+	}
+
+	for _, test := range tests {
+		pool := &node_pool_struct{nodes: test.nodes, active: test.active}
+		if best := pool.best(); best != test.best {
+			t.Errorf("%s: %+v, want %+v", test.name, best, test.best)
+		}
+	}
+}
+
+func TestNodePoolFailover(t *testing.T) {
+
+	first, first_ledger := fake_ledger_node(t, 1, 0)
+	second, second_ledger := fake_ledger_node(t, 1, 0)
+	// the slower second node is only used on failover
+	second_ledger.delay = 100 * time.Millisecond
+
+	pool, err := new_node_pool([]string{first.URL + "/v1", second.URL + "/v1"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pool.probe() || pool.client().url != first.URL+"/v1" {
+		t.Fatalf("switched to %s while the first node is healthy", pool.client().url)
+	}
+
+	// the active node falls behind
+	first_ledger.set(1, time.Minute, 0)
+	if !pool.probe() || pool.client().url != second.URL+"/v1" {
+		t.Errorf("active %s after the first node lags, want the second", pool.client().url)
+	}
+
+	// the second node dies, the first one caught up
+	first_ledger.set(1, 0, 0)
+	second_ledger.set(1, 0, 500)
+	if !pool.probe() || pool.client().url != first.URL+"/v1" {
+		t.Errorf("active %s after the second node died, want the first", pool.client().url)
+	}
+
+	// both down, the pool stays on the last node
+	first_ledger.set(1, 0, 503)
+	if pool.probe() || pool.client().url != first.URL+"/v1" {
+		t.Errorf("active %s with every node down, want the last active", pool.client().url)
+	}
+}
+
+func TestNodePoolStart(t *testing.T) {
+
+	first, first_ledger := fake_ledger_node(t, 1, 0)
+	second, _ := fake_ledger_node(t, 1, 0)
+
+	pool, err := new_node_pool([]string{first.URL + "/v1", second.URL + "/v1"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.mutex.Lock()
+	pool.active = pool.nodes[0]
+	pool.mutex.Unlock()
+
+	var switched int32
+	pool.switched = func() { atomic.AddInt32(&switched, 1) }
+
+	first_ledger.set(1, 0, 500)
+	pool.start(10 * time.Millisecond)
+	defer pool.stop()
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&switched) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if atomic.LoadInt32(&switched) != 1 || pool.client().url != second.URL+"/v1" {
+		t.Errorf("%d switches, active %s, want one failover to the second node", atomic.LoadInt32(&switched), pool.client().url)
+	}
+}
+
+func TestNodePoolChainId(t *testing.T) {
+
+	mainnet, _ := fake_ledger_node(t, 1, 0)
+	testnet, testnet_ledger := fake_ledger_node(t, 2, 0)
+
+	// a node of another network is never used
+	pool, err := new_node_pool([]string{testnet.URL + "/v1", mainnet.URL + "/v1"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pool.client().url != mainnet.URL+"/v1" {
+		t.Errorf("active %s, want the mainnet node", pool.client().url)
+	}
+	if node := pool_node(pool, testnet.URL+"/v1"); node.healthy || node.reason != "chain id 2, expected 1" {
+		t.Errorf("testnet node healthy %t %q", node.healthy, node.reason)
+	}
+
+	// only nodes of another network fail the pool
+	_, err = new_node_pool([]string{testnet.URL + "/v1"}, 1)
+	if err == nil || !strings.Contains(err.Error(), "chain id 2, expected 1") {
+		t.Errorf("error %v, want the chain id rejection", err)
+	}
+
+	// without an expected chain id the first responding node sets it
+	pool, err = new_node_pool([]string{dead_node(t), testnet.URL + "/v1", mainnet.URL + "/v1"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pool.chain_id != 2 || pool.client().url != testnet.URL+"/v1" || pool.client().chain_id != 2 {
+		t.Errorf("chain id %d active %s, want the first responding node", pool.chain_id, pool.client().url)
+	}
+	if node := pool_node(pool, mainnet.URL+"/v1"); node.healthy || node.reason != "chain id 1, expected 2" {
+		t.Errorf("mainnet node healthy %t %q", node.healthy, node.reason)
+	}
+
+	// a node moving to another network is dropped on the next probe
+	if pool, err = new_node_pool([]string{testnet.URL + "/v1"}, 2); err != nil {
+		t.Fatal(err)
+	}
+	testnet_ledger.set(3, 0, 0)
+	pool.probe()
+	if node := pool.nodes[0]; node.healthy || node.reason != "chain id 3, expected 2" {
+		t.Errorf("node healthy %t %q after a chain id change", node.healthy, node.reason)
+	}
+}
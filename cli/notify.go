@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+/*
+--------------------Discord--------------------
+*/
+func send_discord(Config *config_struct, result txn_result_struct, nft_info nft_info, message string) error {
+
+	if Config.Discord.Hook == "" {
+		return nil
+	}
+
+	var embed_color int
+	switch result.status {
+	case txn_committed:
+		embed_color = 0x57f287
+	case txn_pending:
+		// only final outcomes are sent
+		return nil
+	case ownership_mismatch:
+		embed_color = 0xfee75c
+	default:
+		if !Config.Discord.Send_fail {
+			return nil
+		}
+		embed_color = 0xed4245
+	}
+
+	embed := map[string]interface{}{
+		"title":       message,
+		"description": "Transaction: " + result.hash,
+		"color":       embed_color,
+		"fields": []map[string]interface{}{
+			{"name": "Status", "value": result.status, "inline": true},
+			{"name": "Wallet", "value": Config.buyer(nft_info).address_str, "inline": true},
+		},
+	}
+	if nft_info.image != "" {
+		embed["thumbnail"] = map[string]string{"url": nft_info.image}
+	}
+
+	hook, _ := json.Marshal(map[string]interface{}{
+		"username": "Aptos Sniper",
+		"embeds":   []interface{}{embed},
+	})
+
+	req, _ := http.NewRequest("POST", Config.Discord.Hook, bytes.NewReader(hook))
+	req.Header.Add("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.New("discord: error send hook")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return errors.New("discord: hook status " + res.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gookit/color"
+	"golang.org/x/crypto/sha3"
+)
+
+/*
+--------------------Ownership--------------------
+*/
+const ownership_mismatch = "mismatch"
+
+// delay between ownership checks while the node catches up
+var ownership_retry = 1000 * time.Millisecond
+
+// verify_purchases checks a single purchase or every listing of a sweep
+func verify_purchases(Config *config_struct, nft_info nft_info, hash string) {
+
+	if len(nft_info.swept) == 0 {
+		if nft_info.creator != "" {
+			verify_purchase(Config, nft_info, hash)
+		}
+		return
+	}
+
+	for _, listing := range nft_info.swept {
+		listing.wallet = nft_info.wallet
+		if listing.creator == "" {
+			fmt.Printf("[%s] [%s] %s\n",
+				color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+				color.Red.Text("ERROR  "),
+				color.Red.Text("Ownership check skipped for "+listing.token_name+": creator unknown"),
+			)
+
+			continue
+		}
+
+		verify_purchase(Config, listing, hash)
+	}
+}
+
+// verify_purchase checks that a committed purchase really moved the token to the wallet
+func verify_purchase(Config *config_struct, nft_info nft_info, hash string) {
+
+	var owned bool
+	var detail string
+	var err error
+
+	// a lagging node may not have the purchase yet
+	for attempt := 0; attempt < 3; attempt++ {
+		owned, detail, err = verify_ownership(Config.node(), Config.buyer(nft_info).address_str, nft_info)
+		if err == nil && owned {
+			break
+		}
+		time.Sleep(ownership_retry)
+	}
+
+	if err != nil {
+		fmt.Printf("[%s] [%s] %s\n",
+			color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+			color.Red.Text("ERROR  "),
+			color.Red.Text("Ownership check failed for "+nft_info.token_name+": "+err.Error()),
+		)
+
+		return
+	}
+
+	if owned {
+		fmt.Printf("[%s] [%s] %s\n",
+			color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+			color.Green.Text("SUCCESS"),
+			color.Green.Text("Ownership verified "+nft_info.token_name+" ("+detail+")"),
+		)
+
+		return
+	}
+
+	message := "OWNERSHIP MISMATCH: " + nft_info.token_name + " is not in the wallet (" + detail + ")"
+
+	fmt.Printf("[%s] [%s] %s\n",
+		color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+		color.BgRed.Text("WARNING"),
+		color.BgRed.Text(message),
+	)
+
+	send_discord(Config, txn_result_struct{status: ownership_mismatch, hash: hash}, nft_info, message)
+}
+
+// verify_ownership looks in the TokenStore (token v1), then at the token object owner (token v2)
+func verify_ownership(client client_struct, owner string, nft_info nft_info) (bool, string, error) {
+
+	if nft_info.token_address == "" {
+		owned, err := token_v1_owned(client, owner, nft_info)
+		if err != nil {
+			return false, "", err
+		}
+		if owned {
+			return true, "token v1", nil
+		}
+	}
+
+	token_address := nft_info.token_address
+	if token_address == "" {
+		token_address = token_v2_address(nft_info.creator, nft_info.collection, nft_info.token_name)
+	}
+
+	object_owner, err := object_owner(client, token_address)
+	if err != nil {
+		return false, "", err
+	}
+	if object_owner == "" {
+		return false, "not found as token v1 or v2", nil
+	}
+
+	a, _ := parse_address(object_owner)
+	b, _ := parse_address(owner)
+	if a != b {
+		return false, "token v2 owned by " + object_owner, nil
+	}
+
+	return true, "token v2", nil
+}
+
+func token_v1_owned(client client_struct, owner string, nft_info nft_info) (bool, error) {
+
+	status, body, err := node_get(client.accounts + owner + "/resource/0x3::token::TokenStore")
+	if err != nil {
+		return false, err
+	}
+	if status == 404 {
+		// wallet never held a token v1
+		return false, nil
+	}
+
+	var store struct {
+		Data struct {
+			Tokens struct {
+				Handle string `json:"handle"`
+			} `json:"tokens"`
+		} `json:"data"`
+	}
+	if err = json.Unmarshal(body, &store); err != nil || store.Data.Tokens.Handle == "" {
+		return false, errors.New("ownership: error decode token store")
+	}
+
+	property_version := nft_info.property_version
+	if property_version == "" {
+		property_version = "0"
+	}
+
+	item, _ := json.Marshal(map[string]interface{}{
+		"key_type":   "0x3::token::TokenId",
+		"value_type": "0x3::token::Token",
+		"key": map[string]interface{}{
+			"token_data_id": map[string]string{
+				"creator":    nft_info.creator,
+				"collection": nft_info.collection,
+				"name":       nft_info.token_name,
+			},
+			"property_version": property_version,
+		},
+	})
+
+	req, _ := http.NewRequest("POST", client.url+"/tables/"+store.Data.Tokens.Handle+"/item", bytes.NewReader(item))
+	req.Header.Add("Content-Type", "application/json")
+	res, err := node_client.Do(req)
+	if err != nil {
+		return false, errors.New("ownership: node request error")
+	}
+	defer res.Body.Close()
+
+	if body, err = ioutil.ReadAll(res.Body); err != nil {
+		return false, errors.New("ownership: error read body")
+	}
+	if res.StatusCode == 404 {
+		return false, nil
+	}
+
+	var token struct {
+		Amount string `json:"amount"`
+	}
+	if err = json.Unmarshal(body, &token); err != nil {
+		return false, errors.New("ownership: error decode token")
+	}
+	amount, _ := strconv.ParseUint(token.Amount, 10, 64)
+
+	return amount > 0, nil
+}
+
+// object_owner returns "" when the object does not exist
+func object_owner(client client_struct, address string) (string, error) {
+
+	status, body, err := node_get(client.accounts + address + "/resource/0x1::object::ObjectCore")
+	if err != nil {
+		return "", err
+	}
+	if status == 404 {
+		return "", nil
+	}
+
+	var core struct {
+		Data struct {
+			Owner string `json:"owner"`
+		} `json:"data"`
+	}
+	if err = json.Unmarshal(body, &core); err != nil {
+		return "", errors.New("ownership: error decode object")
+	}
+
+	return core.Data.Owner, nil
+}
+
+// token_v2_address is the named token object address, sha3(creator || collection::name || 0xFE)
+func token_v2_address(creator string, collection string, name string) string {
+	address, _ := parse_address(creator)
+
+	data := append(address[:], []byte(collection+"::"+name)...)
+	data = append(data, 0xFE)
+
+	return fmt.Sprintf("0x%x", sha3.Sum256(data))
+}
+
+func node_get(url string) (int, []byte, error) {
+
+	req, _ := http.NewRequest("GET", url, nil)
+	res, err := node_client.Do(req)
+	if err != nil {
+		return 0, nil, errors.New("node request error")
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return res.StatusCode, nil, errors.New("error read body")
+	}
+	if res.StatusCode != 200 && res.StatusCode != 404 {
+		return res.StatusCode, body, fmt.Errorf("node status %s", res.Status)
+	}
+
+	return res.StatusCode, body, nil
+}
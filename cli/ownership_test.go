@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	test_owner      = "0xcafe"
+	test_creator    = "0xbeef"
+	test_collection = "Bruh Bears"
+	test_token_name = "Bruh Bear #1"
+)
+
+type fake_ownership_node_struct struct {
+	mutex      sync.Mutex
+	store      bool   // the owner has a TokenStore
+	amount     string // token v1 amount in the table, "" when missing
+	object     string // token object address
+	owner      string // token object owner, "" when the object is missing
+	keys       []string
+	mismatches int
+}
+
+// fake_ownership_node serves the TokenStore, its table, the ObjectCore and the discord hook
+func fake_ownership_node(t *testing.T, node *fake_ownership_node_struct) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		node.mutex.Lock()
+		defer node.mutex.Unlock()
+
+		switch {
+		case r.URL.Path == "/v1/accounts/"+test_owner+"/resource/0x3::token::TokenStore" && node.store:
+			w.Write([]byte(`{"type":"0x3::token::TokenStore","data":{"tokens":{"handle":"0xab12"}}}`))
+
+		case r.URL.Path == "/v1/tables/0xab12/item" && r.Method == "POST":
+			body, _ := ioutil.ReadAll(r.Body)
+			node.keys = append(node.keys, string(body))
+			if node.amount == "" {
+				w.WriteHeader(404)
+				w.Write([]byte(`{"message":"Table Item not found","error_code":"table_item_not_found"}`))
+				return
+			}
+			w.Write([]byte(`{"amount":"` + node.amount + `","id":{}}`))
+
+		case r.URL.Path == "/v1/accounts/"+node.object+"/resource/0x1::object::ObjectCore" && node.owner != "":
+			w.Write([]byte(`{"type":"0x1::object::ObjectCore","data":{"allow_ungated_transfer":true,"owner":"` + node.owner + `"}}`))
+
+		case r.URL.Path == "/discord":
+			node.mismatches++
+			w.WriteHeader(204)
+
+		default:
+			w.WriteHeader(404)
+			w.Write([]byte(`{"message":"Resource not found","error_code":"resource_not_found"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestVerifyPurchase(t *testing.T) {
+
+	ownership_retry = 10 * time.Millisecond
+	defer func() { ownership_retry = 1000 * time.Millisecond }()
+
+	named_v2 := token_v2_address(test_creator, test_collection, test_token_name)
+
+	tests := []struct {
+		name     string
+		node     fake_ownership_node_struct
+		address  string
+		mismatch bool
+		v1       bool
+	}{
+		{"token v1", fake_ownership_node_struct{store: true, amount: "1"}, "", false, true},
+		// a v1 store without the token falls through to the named v2 object
+		{"token v1 not in store", fake_ownership_node_struct{store: true, object: named_v2, owner: "0xdead"}, "", true, true},
+		{"named token v2", fake_ownership_node_struct{object: named_v2, owner: "0x000cafe"}, "", false, false},
+		{"token v2 object", fake_ownership_node_struct{object: "0x5678", owner: test_owner}, "0x5678", false, false},
+		{"token v2 other owner", fake_ownership_node_struct{object: "0x5678", owner: "0xdead"}, "0x5678", true, false},
+		{"not found", fake_ownership_node_struct{object: "0x5678"}, "0x5678", true, false},
+	}
+
+	for i := range tests {
+		test := &tests[i]
+		server := fake_ownership_node(t, &test.node)
+
+		Config := test_broadcast_config(server)
+		Config.Discord.Hook = server.URL + "/discord"
+
+		verify_purchase(Config, nft_info{
+			token_name:    test_token_name,
+			collection:    test_collection,
+			creator:       test_creator,
+			token_address: test.address,
+			wallet:        &wallet_struct{address_str: test_owner},
+		}, "0xaa")
+
+		test.node.mutex.Lock()
+		if (test.node.mismatches > 0) != test.mismatch {
+			t.Errorf("%s: %d mismatch reports, want mismatch %t", test.name, test.node.mismatches, test.mismatch)
+		}
+		if !test.v1 && len(test.node.keys) != 0 {
+			t.Errorf("%s: token v1 table read for a token v2 object", test.name)
+		}
+		test.node.mutex.Unlock()
+	}
+}
+
+func TestTokenV1TableKey(t *testing.T) {
+
+	node := &fake_ownership_node_struct{store: true, amount: "1"}
+	server := fake_ownership_node(t, node)
+
+	owned, detail, err := verify_ownership(new_client(server.URL+"/v1", 1), test_owner, nft_info{
+		token_name: test_token_name,
+		collection: test_collection,
+		creator:    test_creator,
+	})
+	if err != nil || !owned || detail != "token v1" {
+		t.Fatalf("%t %s %v, want owned as token v1", owned, detail, err)
+	}
+
+	var key struct {
+		Key_type   string `json:"key_type"`
+		Value_type string `json:"value_type"`
+		Key        struct {
+			Token_data_id struct {
+				Creator    string `json:"creator"`
+				Collection string `json:"collection"`
+				Name       string `json:"name"`
+			} `json:"token_data_id"`
+			Property_version string `json:"property_version"`
+		} `json:"key"`
+	}
+	if len(node.keys) != 1 || json.Unmarshal([]byte(node.keys[0]), &key) != nil {
+		t.Fatalf("table keys %v", node.keys)
+	}
+
+	// a missing property version is the original token
+	if key.Key_type != "0x3::token::TokenId" || key.Value_type != "0x3::token::Token" ||
+		key.Key.Token_data_id.Creator != test_creator || key.Key.Token_data_id.Collection != test_collection ||
+		key.Key.Token_data_id.Name != test_token_name || key.Key.Property_version != "0" {
+		t.Errorf("table key %s", node.keys[0])
+	}
+}
+
+func TestTokenV1ZeroAmount(t *testing.T) {
+
+	server := fake_ownership_node(t, &fake_ownership_node_struct{store: true, amount: "0"})
+
+	owned, err := token_v1_owned(new_client(server.URL+"/v1", 1), test_owner, nft_info{creator: test_creator})
+	if err != nil || owned {
+		t.Errorf("%t %v, want a zero amount not owned", owned, err)
+	}
+}
+
+func TestVerifySweep(t *testing.T) {
+
+	ownership_retry = 10 * time.Millisecond
+	defer func() { ownership_retry = 1000 * time.Millisecond }()
+
+	var mutex sync.Mutex
+	var checked []string
+	mismatches := 0
+
+	// 0x5678 is in the wallet, 0x9abc was bought by someone else
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		switch r.URL.Path {
+		case "/v1/accounts/0x5678/resource/0x1::object::ObjectCore":
+			checked = append(checked, "0x5678")
+			w.Write([]byte(`{"data":{"owner":"` + test_owner + `"}}`))
+		case "/v1/accounts/0x9abc/resource/0x1::object::ObjectCore":
+			checked = append(checked, "0x9abc")
+			w.Write([]byte(`{"data":{"owner":"0xdead"}}`))
+		case "/discord":
+			mismatches++
+			w.WriteHeader(204)
+		default:
+			checked = append(checked, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	Config := test_broadcast_config(server)
+	Config.Discord.Hook = server.URL + "/discord"
+
+	verify_purchases(Config, nft_info{
+		token_name: "#1, #2, #3",
+		mode:       "sweep",
+		wallet:     &wallet_struct{address_str: test_owner},
+		swept: []nft_info{
+			{token_name: "#1", creator: test_creator, token_address: "0x5678"},
+			{token_name: "#2", creator: test_creator, token_address: "0x9abc"},
+			// without a creator the listing can not be looked up
+			{token_name: "#3", token_address: "0xdef0"},
+		},
+	}, "0xaa")
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if mismatches != 1 {
+		t.Errorf("%d mismatch reports, want 1 for #2", mismatches)
+	}
+	if len(checked) < 2 || checked[0] != "0x5678" || checked[len(checked)-1] != "0x9abc" {
+		t.Errorf("checked %v, want 0x5678 then 0x9abc only", checked)
+	}
+	for _, address := range checked {
+		if address != "0x5678" && address != "0x9abc" {
+			t.Errorf("unexpected request %s", address)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gookit/color"
+	"github.com/paulrademacher/climenu"
+)
+
+/*
+--------------------Custom function--------------------
+*/
+func custom_function(Config *config_struct) {
+
+	Clear(4, "action > aptos sniper > custom function", "info")
+
+	payload, ok := ask_entry_function(Config)
+	if !ok {
+		return
+	}
+
+	if !ask_simulate(Config) {
+		return
+	}
+
+	if !ask_fee_payer(Config) {
+		return
+	}
+
+	name := payload.Function
+	if payload.Type == "script_payload" {
+		name = "script"
+	}
+
+	menu := climenu.NewButtonMenu("", "Submit "+name)
+	menu.AddMenuItem("Yes", "true")
+	menu.AddMenuItem("No", "false")
+
+	submit, escaped := menu.Run()
+	Clear(3, nil, nil)
+	if escaped || submit == "false" {
+		return
+	}
+
+	send_transaction(Config, payload, nft_info{
+		token_name:  name,
+		mode:        "custom",
+		marketplace: "custom",
+		collection:  name,
+	})
+
+	color.Warn.Tips("Press enter for back.")
+	fmt.Scanln()
+}
+
+// ask_entry_function reads the function from the menu or a json file and validates it against the module abi, or a move script
+func ask_entry_function(Config *config_struct) (payload_struct, bool) {
+
+	menu := climenu.NewButtonMenu("", "Function input")
+	menu.AddMenuItem("Enter in menu", "menu")
+	menu.AddMenuItem("Load json file", "file")
+	menu.AddMenuItem("Move script", "script")
+
+	source, escaped := menu.Run()
+	Clear(3, nil, nil)
+	if escaped {
+		return payload_struct{}, false
+	}
+
+	if source == "script" {
+		return ask_script(Config)
+	}
+
+	if source == "file" {
+		path := strings.TrimSpace(climenu.GetText("Function file", `eg: claim.json {"function": "0x...::claim::claim", "type_arguments": [], "arguments": []}`))
+		Clear(1, nil, nil)
+
+		payload, abi, err := load_entry_function(Config, path)
+		if err == nil {
+			err = abi.validate(&payload)
+		}
+		if err != nil {
+			color.Warn.Tips(err.Error() + ". Press enter for back.")
+			fmt.Scanln()
+			return payload_struct{}, false
+		}
+
+		print_entry_function(payload)
+		return payload, true
+	}
+
+	function := strings.TrimSpace(climenu.GetText("Function", "eg: 0x...::minting::mint"))
+	Clear(1, nil, nil)
+
+	abi, err := fetch_function_abi(Config.node(), function)
+	if err != nil {
+		color.Warn.Tips(err.Error() + ". Press enter for back.")
+		fmt.Scanln()
+		return payload_struct{}, false
+	}
+	fmt.Printf("%s %s\n", color.Magenta.Text("Function  "), function)
+
+	payload := payload_struct{
+		Type:          "entry_function_payload",
+		Function:      function,
+		TypeArguments: []string{},
+		Arguments:     []interface{}{},
+	}
+
+	if len(abi.Generic_type_params) > 0 {
+		for {
+			input := climenu.GetText(fmt.Sprintf("Type arguments [%d]", len(abi.Generic_type_params)), "eg: 0x1::aptos_coin::AptosCoin")
+			Clear(1, nil, nil)
+
+			payload.TypeArguments = []string{}
+			for _, type_argument := range strings.Split(input, ",") {
+				if type_argument = strings.TrimSpace(type_argument); type_argument != "" {
+					payload.TypeArguments = append(payload.TypeArguments, type_argument)
+				}
+			}
+
+			if len(payload.TypeArguments) == len(abi.Generic_type_params) {
+				fmt.Printf("%s %s\n", color.Magenta.Text("Types     "), strings.Join(payload.TypeArguments, ", "))
+				break
+			}
+		}
+	}
+
+	argument_types := abi.argument_types(payload.TypeArguments)
+	for len(argument_types) > 0 {
+		input := climenu.GetText("Arguments ["+strings.Join(argument_types, ", ")+"]", `eg: ["0x1", "2"]`)
+		Clear(1, nil, nil)
+
+		var arguments []interface{}
+		if err := unmarshal_arguments([]byte(input), &arguments); err != nil {
+			color.Redln("Arguments must be a json array")
+			continue
+		}
+
+		payload.Arguments = arguments
+		if err := abi.validate(&payload); err != nil {
+			color.Redln(err.Error())
+			continue
+		}
+
+		fmt.Printf("%s %s\n", color.Magenta.Text("Arguments "), input)
+		break
+	}
+
+	if err := abi.validate(&payload); err != nil {
+		color.Warn.Tips(err.Error() + ". Press enter for back.")
+		fmt.Scanln()
+		return payload_struct{}, false
+	}
+
+	return payload, true
+}
+
+// load_entry_function reads a payload file, the same shape as an entry_function_payload
+func load_entry_function(Config *config_struct, path string) (payload_struct, function_abi_struct, error) {
+
+	var payload payload_struct
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return payload, function_abi_struct{}, errors.New("error read " + path)
+	}
+	if err = unmarshal_arguments(data, &payload); err != nil {
+		return payload, function_abi_struct{}, errors.New("error decode " + path + ": " + err.Error())
+	}
+
+	payload.Type = "entry_function_payload"
+	if payload.TypeArguments == nil {
+		payload.TypeArguments = []string{}
+	}
+	if payload.Arguments == nil {
+		payload.Arguments = []interface{}{}
+	}
+
+	abi, err := fetch_function_abi(Config.node(), payload.Function)
+
+	return payload, abi, err
+}
+
+func print_entry_function(payload payload_struct) {
+
+	arguments, _ := json.Marshal(payload.Arguments)
+
+	fmt.Printf("%s %s\n", color.Magenta.Text("Function  "), payload.Function)
+	if len(payload.TypeArguments) > 0 {
+		fmt.Printf("%s %s\n", color.Magenta.Text("Types     "), strings.Join(payload.TypeArguments, ", "))
+	}
+	fmt.Printf("%s %s\n", color.Magenta.Text("Arguments "), arguments)
+}
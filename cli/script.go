@@ -0,0 +1,317 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gookit/color"
+	"github.com/paulrademacher/climenu"
+)
+
+/*
+--------------------Scripts--------------------
+*/
+type script_def_struct struct {
+	file           string // compiled from scripts/sources into the scripts folder
+	description    string
+	contract       string // marketplace called by the bytecode
+	argument_types []string
+}
+
+var script_names = []string{"sweep_topaz"}
+
+var script_library = map[string]script_def_struct{
+	"sweep_topaz": {
+		file:        "sweep_topaz.mv",
+		description: "topaz token v1 listings in one transaction",
+		contract:    "topaz",
+		argument_types: []string{
+			"vector<address>",             // sellers
+			"vector<u64>",                 // prices
+			"vector<address>",             // creators
+			"vector<0x1::string::String>", // collections
+			"vector<0x1::string::String>", // names
+			"vector<u64>",                 // property versions
+		},
+	},
+}
+
+// named addresses of scripts/Move.toml, compiled into the bytecode
+var script_addresses = map[string]string{
+	"topaz": "0x2c7bccf7b31baf770fdbcc768d9e9cb3d87805e255355df5db32ac9a669010a2",
+}
+
+// script file for the custom function runner, script is a bundled name or a .mv path
+type script_file_struct struct {
+	Script         string      `json:"script"`
+	Type_arguments []string    `json:"type_arguments"`
+	Argument_types []string    `json:"argument_types"`
+	Arguments      interface{} `json:"arguments"`
+}
+
+func scripts_dir() string {
+	path, _ := filepath.Abs(filepath.Dir(os.Args[0]))
+	return filepath.Join(path, "scripts")
+}
+
+func load_script(path string) (*code_struct, error) {
+
+	code, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.New("error read script " + path)
+	}
+	if len(code) == 0 {
+		return nil, errors.New("empty script " + path)
+	}
+
+	return &code_struct{Bytecode: fmt.Sprintf("0x%x", code)}, nil
+}
+
+// script_payload loads a bundled script by name or a compiled .mv file
+func script_payload(script string, type_arguments []string, argument_types []string, arguments interface{}) (payload_struct, error) {
+
+	path := script
+	if def, ok := script_library[script]; ok {
+		path = filepath.Join(scripts_dir(), def.file)
+		argument_types = def.argument_types
+	}
+
+	code, err := load_script(path)
+	if err != nil {
+		return payload_struct{}, err
+	}
+
+	if type_arguments == nil {
+		type_arguments = []string{}
+	}
+	if arguments == nil {
+		arguments = []interface{}{}
+	}
+
+	payload := payload_struct{
+		Type:          "script_payload",
+		Code:          code,
+		TypeArguments: type_arguments,
+		Arguments:     arguments,
+		ArgumentTypes: argument_types,
+	}
+
+	if _, err := payload.script(); err != nil {
+		return payload_struct{}, err
+	}
+
+	return payload, nil
+}
+
+// script_usable checks the bundled script is compiled and calls the contracts of the network
+func (Config *config_struct) script_usable(name string) error {
+
+	def := script_library[name]
+	if _, err := os.Stat(filepath.Join(scripts_dir(), def.file)); err != nil {
+		return errors.New(def.file + " not compiled")
+	}
+
+	contracts := map[string]string{
+		"topaz":    Config.network().Contracts.Topaz,
+		"bluemove": Config.network().Contracts.Bluemove,
+	}
+	if contracts[def.contract] != script_addresses[def.contract] {
+		return fmt.Errorf("%s is compiled for %s %s, %s uses %q", def.file, def.contract, script_addresses[def.contract], Config.network_name(), contracts[def.contract])
+	}
+
+	return nil
+}
+
+// ask_script picks a bundled script or loads a script file for the custom function runner
+func ask_script(Config *config_struct) (payload_struct, bool) {
+
+	menu := climenu.NewButtonMenu("", "Choose script")
+	for _, name := range script_names {
+		if Config.script_usable(name) == nil {
+			menu.AddMenuItem(name+" - "+script_library[name].description, name)
+		}
+	}
+	menu.AddMenuItem("Load json file", "file")
+
+	name, escaped := menu.Run()
+	Clear(3, nil, nil)
+	if escaped {
+		return payload_struct{}, false
+	}
+
+	if name == "file" {
+		path := strings.TrimSpace(climenu.GetText("Script file", `eg: buy.json {"script": "scripts/buy.mv", "argument_types": ["address"], "arguments": ["0x1"]}`))
+		Clear(1, nil, nil)
+
+		var file script_file_struct
+		data, err := os.ReadFile(path)
+		if err == nil {
+			err = unmarshal_arguments(data, &file)
+		}
+		if err != nil {
+			color.Warn.Tips("error load " + path + ". Press enter for back.")
+			fmt.Scanln()
+			return payload_struct{}, false
+		}
+
+		// relative .mv paths are next to the json file
+		if _, ok := script_library[file.Script]; !ok && !filepath.IsAbs(file.Script) {
+			file.Script = filepath.Join(filepath.Dir(path), file.Script)
+		}
+
+		payload, err := script_payload(file.Script, file.Type_arguments, file.Argument_types, file.Arguments)
+		if err != nil {
+			color.Warn.Tips(err.Error() + ". Press enter for back.")
+			fmt.Scanln()
+			return payload_struct{}, false
+		}
+
+		fmt.Printf("%s %s\n", color.Magenta.Text("Script    "), file.Script)
+		return payload, true
+	}
+
+	fmt.Printf("%s %s\n", color.Magenta.Text("Script    "), name)
+
+	for {
+		input := climenu.GetText("Arguments ["+strings.Join(script_library[name].argument_types, ", ")+"]", `eg: [["0x1"], ["100"]]`)
+		Clear(1, nil, nil)
+
+		var arguments []interface{}
+		if err := unmarshal_arguments([]byte(input), &arguments); err != nil {
+			color.Redln("Arguments must be a json array")
+			continue
+		}
+
+		payload, err := script_payload(name, nil, nil, arguments)
+		if err != nil {
+			color.Redln(err.Error())
+			continue
+		}
+
+		fmt.Printf("%s %s\n", color.Magenta.Text("Arguments "), input)
+		return payload, true
+	}
+}
+
+/*
+----------Sweep----------
+*/
+
+// sweep_payload buys token v1 listings of one marketplace, topaz with the bundled script, bluemove with its batch function
+func sweep_payload(Config *config_struct, listings []nft_info) (payload_struct, error) {
+
+	marketplace := listings[0].marketplace
+	for _, listing := range listings {
+		if listing.marketplace != marketplace {
+			return payload_struct{}, errors.New("sweep: listings of several marketplaces")
+		}
+	}
+
+	if marketplace == "bluemove" {
+		return bluemove_batch_payload(Config, listings...), nil
+	}
+	if marketplace != "topaz" {
+		return payload_struct{}, errors.New("sweep: unsupported marketplace " + marketplace)
+	}
+
+	arguments := make([][]string, 6)
+	for i := range arguments {
+		arguments[i] = []string{}
+	}
+
+	for _, listing := range listings {
+		arguments[0] = append(arguments[0], listing.seller)
+		arguments[1] = append(arguments[1], fmt.Sprintf("%d", int(listing.price)))
+		arguments[2] = append(arguments[2], listing.creator)
+		arguments[3] = append(arguments[3], listing.collection)
+		arguments[4] = append(arguments[4], listing.token_name)
+		arguments[5] = append(arguments[5], listing.property_version)
+	}
+
+	return script_payload("sweep_topaz", nil, nil, arguments)
+}
+
+// ask_sweep offers one transaction per poll, topaz needs the compiled sweep_topaz script
+func ask_sweep(Config *config_struct, marketplace string, version string) bool {
+
+	Config.session.sweep = false
+	if version != token_v1 {
+		return true
+	}
+
+	if marketplace == "topaz" {
+		if err := Config.script_usable("sweep_topaz"); err != nil {
+			fmt.Printf("%s %s\n", color.Magenta.Text("Sweep     "), color.Gray.Text("unavailable, "+err.Error()))
+			return true
+		}
+	}
+
+	menu := climenu.NewButtonMenu("", "Sweep new listings in one transaction")
+	menu.AddMenuItem("No", "false")
+	menu.AddMenuItem("Yes", "true")
+
+	sweep, escaped := menu.Run()
+	if escaped {
+		return false
+	}
+
+	Clear(3, nil, nil)
+
+	Config.session.sweep = sweep == "true"
+	fmt.Printf("%s %t\n", color.Magenta.Text("Sweep     "), Config.session.sweep)
+
+	return true
+}
+
+// send_sweep sends the listings found in one poll, a single listing keeps the marketplace payload
+func send_sweep(Config *config_struct, listings []nft_info) {
+
+	var payload payload_struct
+	var err error
+
+	if len(listings) == 1 {
+		switch listings[0].marketplace {
+		case "topaz":
+			payload, err = topaz_payload(Config, listings[0], listings[0].seller, "")
+		default:
+			payload, err = bluemove_payload(Config, listings[0], "")
+		}
+	} else {
+		payload, err = sweep_payload(Config, listings)
+	}
+	if err != nil {
+		fmt.Printf("[%s] [%s] %s\n",
+			color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+			color.Red.Text("ERROR  "),
+			color.Red.Text(err.Error()),
+		)
+
+		return
+	}
+
+	if len(listings) == 1 {
+		send_transaction(Config, payload, listings[0])
+		return
+	}
+
+	var price float64
+	var names []string
+	for _, listing := range listings {
+		price += listing.price
+		names = append(names, listing.token_name)
+	}
+
+	send_transaction(Config, payload, nft_info{
+		token_name:  strings.Join(names, ", "),
+		price:       price,
+		image:       listings[0].image,
+		mode:        "sweep",
+		marketplace: listings[0].marketplace,
+		collection:  listings[0].collection,
+		swept:       listings,
+	})
+}
@@ -0,0 +1,17 @@
+// signature of the topaz buy function, only compiled against, never published
+module topaz::marketplace_v2 {
+    use std::string::String;
+
+    public entry fun buy<CoinType>(
+        _buyer: &signer,
+        _seller: address,
+        _price: u64,
+        _amount: u64,
+        _creator: address,
+        _collection: String,
+        _name: String,
+        _property_version: u64,
+    ) {
+        abort 0
+    }
+}
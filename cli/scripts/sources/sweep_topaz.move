@@ -0,0 +1,32 @@
+// buys every topaz listing, one failed purchase aborts them all
+script {
+    use std::string::String;
+    use std::vector;
+    use aptos_framework::aptos_coin::AptosCoin;
+    use topaz::marketplace_v2;
+
+    fun sweep_topaz(
+        buyer: &signer,
+        sellers: vector<address>,
+        prices: vector<u64>,
+        creators: vector<address>,
+        collections: vector<String>,
+        names: vector<String>,
+        property_versions: vector<u64>,
+    ) {
+        let i = 0;
+        while (i < vector::length(&sellers)) {
+            marketplace_v2::buy<AptosCoin>(
+                buyer,
+                *vector::borrow(&sellers, i),
+                *vector::borrow(&prices, i),
+                1,
+                *vector::borrow(&creators, i),
+                *vector::borrow(&collections, i),
+                *vector::borrow(&names, i),
+                *vector::borrow(&property_versions, i),
+            );
+            i = i + 1;
+        };
+    }
+}
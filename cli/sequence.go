@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+/*
+--------------------Sequence number--------------------
+*/
+type sequence_manager_struct struct {
+	mutex    sync.Mutex
+	account  func() string // https://fullnode.mainnet.aptoslabs.com/v1/accounts/<<ADDRESS>> on the active node
+	next     uint64
+	released []uint64
+}
+
+func new_sequence_manager(account func() string) (*sequence_manager_struct, error) {
+
+	manager := &sequence_manager_struct{account: account}
+
+	sequence_number, err := fetch_sequence_number(account())
+	if err != nil {
+		return nil, err
+	}
+	manager.next = sequence_number
+
+	return manager, nil
+}
+
+func fetch_sequence_number(account string) (uint64, error) {
+
+	req, _ := http.NewRequest("GET", account, nil)
+	req.Header.Add("Content-Type", "application/json")
+	res, err := node_client.Do(req)
+	if err != nil {
+		return 0, errors.New("sequence number: node request error")
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return 0, errors.New("sequence number: error read body")
+	}
+
+	if res.StatusCode != 200 {
+		return 0, fmt.Errorf("sequence number: node status %s", res.Status)
+	}
+
+	var response struct {
+		Sequence_number string `json:"sequence_number"`
+	}
+	if err = json.Unmarshal(body, &response); err != nil {
+		return 0, errors.New("sequence number: error decode body")
+	}
+
+	return strconv.ParseUint(response.Sequence_number, 10, 64)
+}
+
+// allocate hands out the lowest free sequence number, reusing released gaps first
+func (manager *sequence_manager_struct) allocate() uint64 {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	if len(manager.released) > 0 {
+		sequence_number := manager.released[0]
+		manager.released = manager.released[1:]
+		return sequence_number
+	}
+
+	sequence_number := manager.next
+	manager.next++
+
+	return sequence_number
+}
+
+// release returns a number whose transaction never reached mempool
+func (manager *sequence_manager_struct) release(sequence_number uint64) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	if sequence_number >= manager.next {
+		return
+	}
+
+	for _, released := range manager.released {
+		if released == sequence_number {
+			return
+		}
+	}
+
+	manager.released = append(manager.released, sequence_number)
+	sort.Slice(manager.released, func(i, j int) bool { return manager.released[i] < manager.released[j] })
+
+	// shrink the counter while the tail is free
+	for len(manager.released) > 0 && manager.released[len(manager.released)-1] == manager.next-1 {
+		manager.released = manager.released[:len(manager.released)-1]
+		manager.next--
+	}
+}
+
+// reconcile resyncs with the node after SEQUENCE_NUMBER_TOO_OLD or SEQUENCE_NUMBER_TOO_NEW
+func (manager *sequence_manager_struct) reconcile(too_new bool) error {
+
+	on_chain, err := fetch_sequence_number(manager.account())
+	if err != nil {
+		return err
+	}
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	if too_new || on_chain > manager.next {
+		// everything above the on-chain value is lost or stuck, start over from it
+		manager.next = on_chain
+		manager.released = nil
+		return nil
+	}
+
+	// numbers below the on-chain value are already used
+	released := manager.released[:0]
+	for _, sequence_number := range manager.released {
+		if sequence_number >= on_chain {
+			released = append(released, sequence_number)
+		}
+	}
+	manager.released = released
+
+	return nil
+}
+
+func sequence_number_error(message string) (bool, bool) {
+	return strings.Contains(message, "SEQUENCE_NUMBER_TOO_OLD"), strings.Contains(message, "SEQUENCE_NUMBER_TOO_NEW")
+}
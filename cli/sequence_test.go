@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// fake_account_node serves the account resource with the sequence number in on_chain
+func fake_account_node(t *testing.T, on_chain *uint64) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"sequence_number":"%d","authentication_key":"0x0"}`, atomic.LoadUint64(on_chain))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func test_sequence_manager(t *testing.T, on_chain *uint64) *sequence_manager_struct {
+	t.Helper()
+
+	server := fake_account_node(t, on_chain)
+
+	manager, err := new_sequence_manager(func() string { return server.URL + "/v1/accounts/0x1" })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return manager
+}
+
+func TestSequenceAllocateConcurrent(t *testing.T) {
+
+	on_chain := uint64(42)
+	manager := test_sequence_manager(t, &on_chain)
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	var allocated []uint64
+
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			sequence_number := manager.allocate()
+
+			mutex.Lock()
+			allocated = append(allocated, sequence_number)
+			mutex.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(allocated, func(i, j int) bool { return allocated[i] < allocated[j] })
+	for i, sequence_number := range allocated {
+		if sequence_number != 42+uint64(i) {
+			t.Fatalf("allocated %v, want 42..141 once each", allocated)
+		}
+	}
+	if manager.next != 142 {
+		t.Errorf("next %d, want 142", manager.next)
+	}
+}
+
+func TestSequenceRelease(t *testing.T) {
+
+	on_chain := uint64(10)
+	manager := test_sequence_manager(t, &on_chain)
+
+	for i := 0; i < 5; i++ {
+		manager.allocate() // 10..14
+	}
+
+	// gaps are reused lowest first
+	manager.release(12)
+	manager.release(11)
+	manager.release(11)
+	if got := manager.allocate(); got != 11 {
+		t.Errorf("allocate %d, want gap 11", got)
+	}
+	if got := manager.allocate(); got != 12 {
+		t.Errorf("allocate %d, want gap 12", got)
+	}
+	if got := manager.allocate(); got != 15 {
+		t.Errorf("allocate %d, want 15", got)
+	}
+
+	// a free tail shrinks the counter
+	manager.release(13)
+	manager.release(15)
+	manager.release(14)
+	if manager.next != 13 || len(manager.released) != 0 {
+		t.Errorf("next %d released %v, want 13 and none", manager.next, manager.released)
+	}
+
+	// numbers never handed out are ignored
+	manager.release(20)
+	if manager.next != 13 || len(manager.released) != 0 {
+		t.Errorf("next %d released %v after foreign release", manager.next, manager.released)
+	}
+}
+
+func TestSequenceReconcile(t *testing.T) {
+
+	on_chain := uint64(5)
+	manager := test_sequence_manager(t, &on_chain)
+
+	for i := 0; i < 6; i++ {
+		manager.allocate() // 5..10
+	}
+	manager.release(6)
+	manager.release(8)
+
+	// TOO_OLD: 5..7 landed, the released 6 is used on chain
+	atomic.StoreUint64(&on_chain, 8)
+	if err := manager.reconcile(false); err != nil {
+		t.Fatal(err)
+	}
+	if manager.next != 11 || len(manager.released) != 1 || manager.released[0] != 8 {
+		t.Errorf("too old: next %d released %v, want 11 and [8]", manager.next, manager.released)
+	}
+
+	// TOO_OLD with the chain ahead of us, transactions sent from elsewhere
+	atomic.StoreUint64(&on_chain, 20)
+	if err := manager.reconcile(false); err != nil {
+		t.Fatal(err)
+	}
+	if manager.next != 20 || len(manager.released) != 0 {
+		t.Errorf("chain ahead: next %d released %v, want 20 and none", manager.next, manager.released)
+	}
+
+	// TOO_NEW: numbers above the on-chain value were lost, start over from it
+	manager.allocate()
+	manager.allocate()
+	atomic.StoreUint64(&on_chain, 20)
+	if err := manager.reconcile(true); err != nil {
+		t.Fatal(err)
+	}
+	if got := manager.allocate(); got != 20 {
+		t.Errorf("too new: allocate %d, want 20", got)
+	}
+
+	too_old, too_new := sequence_number_error(`{"message":"Invalid transaction: Type: Validation Code: SEQUENCE_NUMBER_TOO_NEW"}`)
+	if too_old || !too_new {
+		t.Errorf("sequence_number_error %t %t, want false true", too_old, too_new)
+	}
+}
+
+func TestSequenceNodeError(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error_code":"account_not_found"}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	if _, err := new_sequence_manager(func() string { return server.URL }); err == nil {
+		t.Error("expected error for a missing account")
+	}
+}
@@ -0,0 +1,452 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/sha3"
+)
+
+/*
+--------------------Signer--------------------
+*/
+
+// signer_struct signs for one account, the private key may live on another host
+type signer_struct interface {
+	sign(message []byte) (account_authenticator_struct, error)
+	// zero_authenticator is accepted by simulation only
+	zero_authenticator() account_authenticator_struct
+}
+
+// verify checks every signature of the authenticator, MultiEd25519 signatures follow the bitmap
+func (authenticator account_authenticator_struct) verify(message []byte) bool {
+
+	if authenticator.variant == authenticator_ed25519 {
+		return len(authenticator.public_key) == ed25519.PublicKeySize &&
+			len(authenticator.signature) == ed25519.SignatureSize &&
+			ed25519.Verify(authenticator.public_key, message, authenticator.signature)
+	}
+
+	if len(authenticator.public_key)%ed25519.PublicKeySize != 1 || len(authenticator.signature)%ed25519.SignatureSize != 4 {
+		return false
+	}
+
+	count := len(authenticator.public_key) / ed25519.PublicKeySize
+	signatures := authenticator.signature[:len(authenticator.signature)-4]
+	bitmap := authenticator.signature[len(authenticator.signature)-4:]
+
+	signed := 0
+	for index := 0; index < 32; index++ {
+		if bitmap[index/8]&(0x80>>(index%8)) == 0 {
+			continue
+		}
+		if index >= count || (signed+1)*ed25519.SignatureSize > len(signatures) {
+			return false
+		}
+
+		public_key := authenticator.public_key[index*ed25519.PublicKeySize : (index+1)*ed25519.PublicKeySize]
+		signature := signatures[signed*ed25519.SignatureSize : (signed+1)*ed25519.SignatureSize]
+		if !ed25519.Verify(public_key, message, signature) {
+			return false
+		}
+		signed++
+	}
+
+	threshold := authenticator.public_key[len(authenticator.public_key)-1]
+
+	return signed*ed25519.SignatureSize == len(signatures) && signed >= int(threshold)
+}
+
+// signing_message_prefix accepts RawTransaction and RawTransactionWithData signing messages only
+func signing_message_prefix(message []byte) bool {
+
+	if len(message) <= 32 {
+		return false
+	}
+
+	for _, prefix := range [][32]byte{
+		sha3.Sum256([]byte("APTOS::RawTransaction")),
+		sha3.Sum256([]byte("APTOS::RawTransactionWithData")),
+	} {
+		if bytes.Equal(message[:32], prefix[:]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+----------Local key----------
+*/
+type local_signer_struct struct {
+	private_key ed25519.PrivateKey
+}
+
+func (signer local_signer_struct) sign(message []byte) (account_authenticator_struct, error) {
+	return account_authenticator_struct{
+		variant:    authenticator_ed25519,
+		public_key: signer.private_key.Public().(ed25519.PublicKey),
+		signature:  ed25519.Sign(signer.private_key, message),
+	}, nil
+}
+
+func (signer local_signer_struct) zero_authenticator() account_authenticator_struct {
+	return account_authenticator_struct{
+		variant:    authenticator_ed25519,
+		public_key: signer.private_key.Public().(ed25519.PublicKey),
+		signature:  make([]byte, ed25519.SignatureSize),
+	}
+}
+
+/*
+----------Keystore----------
+*/
+
+// keystore_signer_struct keeps a keystore key sealed with a random key of the process,
+// the private key is only in the clear while signing
+type keystore_signer_struct struct {
+	public_key ed25519.PublicKey
+	gcm        cipher.AEAD
+	nonce      []byte
+	sealed     []byte
+}
+
+func new_keystore_signer(seed []byte) (*keystore_signer_struct, error) {
+
+	session := make([]byte, 32)
+	defer wipe(session)
+
+	nonce := make([]byte, 12)
+	if _, err := rand.Read(session); err != nil {
+		return nil, errors.New("keystore signer: error read random")
+	}
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, errors.New("keystore signer: error read random")
+	}
+
+	block, _ := aes.NewCipher(session)
+	gcm, _ := cipher.NewGCM(block)
+
+	private_key := ed25519.NewKeyFromSeed(seed)
+	defer wipe(private_key)
+
+	return &keystore_signer_struct{
+		public_key: private_key.Public().(ed25519.PublicKey),
+		gcm:        gcm,
+		nonce:      nonce,
+		sealed:     gcm.Seal(nil, nonce, seed, nil),
+	}, nil
+}
+
+func (signer *keystore_signer_struct) sign(message []byte) (account_authenticator_struct, error) {
+
+	seed, err := signer.gcm.Open(nil, signer.nonce, signer.sealed, nil)
+	if err != nil {
+		return account_authenticator_struct{}, errors.New("keystore signer: error open key")
+	}
+	defer wipe(seed)
+
+	private_key := ed25519.NewKeyFromSeed(seed)
+	defer wipe(private_key)
+
+	return account_authenticator_struct{
+		variant:    authenticator_ed25519,
+		public_key: signer.public_key,
+		signature:  ed25519.Sign(private_key, message),
+	}, nil
+}
+
+func (signer *keystore_signer_struct) zero_authenticator() account_authenticator_struct {
+	return account_authenticator_struct{
+		variant:    authenticator_ed25519,
+		public_key: signer.public_key,
+		signature:  make([]byte, ed25519.SignatureSize),
+	}
+}
+
+// seal moves the private key of a local wallet into a keystore signer
+func (wallet *wallet_struct) seal() error {
+
+	seed := wallet.privateKey.Seed()
+	defer wipe(seed)
+
+	signer, err := new_keystore_signer(seed)
+	if err != nil {
+		return err
+	}
+
+	wipe(wallet.privateKey)
+	wallet.signer = signer
+	wallet.privateKey = nil
+	wallet.privateKeyStr = ""
+
+	return nil
+}
+
+func wipe(data []byte) {
+	for i := range data {
+		data[i] = 0
+	}
+}
+
+// local_wallets derives the account, the optional gas wallet and the wallets of the keystore from the unlocked keys,
+// their keys are sealed in keystore signers
+func (Config *config_struct) local_wallets() (wallet_struct, *wallet_struct, []wallet_struct, error) {
+
+	var wallet wallet_struct
+	var err error
+
+	// multisig account or mnemonic replace aptos_private_key when configured
+	switch {
+	case len(Config.Multisig.Keys) > 0:
+		wallet, err = new_multi_wallet(Config.Multisig.Keys, Config.Multisig.Public_keys, Config.Multisig.Threshold)
+	case Config.Mnemonic != "":
+		wallet, err = new_mnemonic_wallet(Config.Mnemonic, Config.Index)
+	default:
+		wallet, err = new_wallet(Config.Key)
+	}
+	if err != nil {
+		return wallet_struct{}, nil, nil, errors.New("wrong private key: " + err.Error())
+	}
+	if len(Config.Multisig.Keys) == 0 {
+		if err = wallet.seal(); err != nil {
+			return wallet_struct{}, nil, nil, err
+		}
+	}
+
+	var others []wallet_struct
+	for _, stored := range Config.stored_wallets {
+		other, err := new_wallet(stored.Key)
+		if err != nil {
+			return wallet_struct{}, nil, nil, fmt.Errorf("wrong private key of wallet %s: %s", stored.Name, err.Error())
+		}
+		if err = other.seal(); err != nil {
+			return wallet_struct{}, nil, nil, err
+		}
+		other.name = stored.Name
+		others = append(others, other)
+	}
+
+	if Config.Gas_wallet.Key == "" {
+		return wallet, nil, others, nil
+	}
+
+	gas_wallet, err := new_wallet(Config.Gas_wallet.Key)
+	if err != nil {
+		return wallet_struct{}, nil, nil, errors.New("wrong gas wallet private key: " + err.Error())
+	}
+	if err = gas_wallet.seal(); err != nil {
+		return wallet_struct{}, nil, nil, err
+	}
+
+	return wallet, &gas_wallet, others, nil
+}
+
+// signer_wallets are the wallets of the remote signer or of the keystore
+func (Config *config_struct) signer_wallets() (wallet_struct, *wallet_struct, []wallet_struct, error) {
+	if Config.Remote_signer.Url != "" {
+		return remote_wallets(Config.Remote_signer.Url, Config.Remote_signer.Token)
+	}
+
+	return Config.local_wallets()
+}
+
+/*
+----------Remote----------
+*/
+
+// keys of the signing host by name, account, optional gas_wallet and the keystore wallets
+type remote_key_struct struct {
+	Scheme               string `json:"scheme"` // ed25519 or multi_ed25519
+	Public_key           string `json:"public_key"`
+	Simulation_signature string `json:"simulation_signature"`
+}
+
+type remote_sign_request_struct struct {
+	Key     string `json:"key"`
+	Message string `json:"message"`
+}
+
+type remote_signer_struct struct {
+	url            string
+	token          string
+	key            string
+	variant        uint64
+	public_key     []byte
+	zero_signature []byte
+}
+
+var remote_signer_client = &http.Client{Timeout: 5 * time.Second}
+
+// remote_request calls the signing host with the bearer token
+func remote_request(url string, token string, method string, path string, body []byte) ([]byte, error) {
+
+	req, err := http.NewRequest(method, strings.TrimRight(url, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.New("remote signer: wrong url " + url)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Add("Authorization", "Bearer "+token)
+	}
+
+	res, err := remote_signer_client.Do(req)
+	if err != nil {
+		return nil, errors.New("remote signer: request error " + url)
+	}
+	defer res.Body.Close()
+
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, errors.New("remote signer: error read body")
+	}
+
+	if res.StatusCode != 200 {
+		return nil, fmt.Errorf("remote signer: %s %s", res.Status, strings.TrimSpace(string(data)))
+	}
+
+	return data, nil
+}
+
+func decode_hex(str string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(str, "0x"))
+}
+
+// remote_wallets loads the public keys of the signing host, private keys never leave it
+func remote_wallets(url string, token string) (wallet_struct, *wallet_struct, []wallet_struct, error) {
+
+	body, err := remote_request(url, token, "GET", "/keys", nil)
+	if err != nil {
+		return wallet_struct{}, nil, nil, err
+	}
+
+	var keys map[string]remote_key_struct
+	if err = json.Unmarshal(body, &keys); err != nil {
+		return wallet_struct{}, nil, nil, errors.New("remote signer: error decode keys")
+	}
+
+	new_remote_wallet := func(name string, key remote_key_struct) (wallet_struct, error) {
+
+		signer := &remote_signer_struct{url: url, token: token, key: name}
+
+		switch key.Scheme {
+		case "ed25519":
+			signer.variant = authenticator_ed25519
+		case "multi_ed25519":
+			signer.variant = authenticator_multi_ed25519
+		default:
+			return wallet_struct{}, fmt.Errorf("remote signer: %s has unsupported scheme %q", name, key.Scheme)
+		}
+
+		if signer.public_key, err = decode_hex(key.Public_key); err != nil {
+			return wallet_struct{}, fmt.Errorf("remote signer: %s has wrong public key", name)
+		}
+		if signer.zero_signature, err = decode_hex(key.Simulation_signature); err != nil {
+			return wallet_struct{}, fmt.Errorf("remote signer: %s has wrong simulation signature", name)
+		}
+
+		wallet := signer_wallet(signer, signer.variant, signer.public_key)
+		if signer.variant == authenticator_ed25519 {
+			wallet.publicKey = signer.public_key
+		}
+
+		return wallet, nil
+	}
+
+	account, ok := keys["account"]
+	if !ok {
+		return wallet_struct{}, nil, nil, errors.New("remote signer: no account key on " + url)
+	}
+	wallet, err := new_remote_wallet("account", account)
+	if err != nil {
+		return wallet_struct{}, nil, nil, err
+	}
+
+	// the other keys are wallets of the signing host keystore, in name order
+	var names []string
+	for name := range keys {
+		if name != "account" && name != "gas_wallet" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var others []wallet_struct
+	for _, name := range names {
+		other, err := new_remote_wallet(name, keys[name])
+		if err != nil {
+			return wallet_struct{}, nil, nil, err
+		}
+		other.name = name
+		others = append(others, other)
+	}
+
+	gas_key, ok := keys["gas_wallet"]
+	if !ok {
+		return wallet, nil, others, nil
+	}
+	gas_wallet, err := new_remote_wallet("gas_wallet", gas_key)
+	if err != nil {
+		return wallet_struct{}, nil, nil, err
+	}
+
+	return wallet, &gas_wallet, others, nil
+}
+
+// sign asks the signing host and checks the signature before it is submitted
+func (signer *remote_signer_struct) sign(message []byte) (account_authenticator_struct, error) {
+
+	request, _ := json.Marshal(remote_sign_request_struct{
+		Key:     signer.key,
+		Message: fmt.Sprintf("0x%x", message),
+	})
+
+	body, err := remote_request(signer.url, signer.token, "POST", "/sign", request)
+	if err != nil {
+		return account_authenticator_struct{}, err
+	}
+
+	var response struct {
+		Signature string `json:"signature"`
+	}
+	if err = json.Unmarshal(body, &response); err != nil {
+		return account_authenticator_struct{}, errors.New("remote signer: error decode signature")
+	}
+
+	signature, err := decode_hex(response.Signature)
+	if err != nil {
+		return account_authenticator_struct{}, errors.New("remote signer: wrong signature hex")
+	}
+
+	authenticator := account_authenticator_struct{
+		variant:    signer.variant,
+		public_key: signer.public_key,
+		signature:  signature,
+	}
+	if !authenticator.verify(message) {
+		return account_authenticator_struct{}, errors.New("remote signer: invalid signature for " + signer.key)
+	}
+
+	return authenticator, nil
+}
+
+func (signer *remote_signer_struct) zero_authenticator() account_authenticator_struct {
+	return account_authenticator_struct{
+		variant:    signer.variant,
+		public_key: signer.public_key,
+		signature:  signer.zero_signature,
+	}
+}
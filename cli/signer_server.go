@@ -0,0 +1,194 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gookit/color"
+)
+
+/*
+--------------------Signer server--------------------
+*/
+
+// token for the signer server, the -token flag takes precedence
+const signer_token_env = "APTOS_SIGNER_TOKEN"
+
+type signer_server_struct struct {
+	token   string
+	wallets map[string]wallet_struct // account, optional gas_wallet and the keystore wallets
+}
+
+// keystore_wallets unlocks the keystore next to config.json, the node and discord hook are not needed to sign
+func keystore_wallets() (map[string]wallet_struct, error) {
+
+	Config, path, err := read_config()
+	if err != nil {
+		return nil, err
+	}
+	if Config.Remote_signer.Url != "" {
+		return nil, errors.New("remote_signer is set, the signer server signs with its own keystore")
+	}
+
+	if err = Config.unlock_keystore(path); err != nil {
+		return nil, err
+	}
+
+	wallet, gas_wallet, others, err := Config.local_wallets()
+	if err != nil {
+		return nil, err
+	}
+
+	wallets := map[string]wallet_struct{"account": wallet}
+	if gas_wallet != nil {
+		wallets["gas_wallet"] = *gas_wallet
+	}
+	for _, other := range others {
+		wallets[other.name] = other
+	}
+
+	return wallets, nil
+}
+
+func headless_signer(args []string) int {
+
+	flags := flag.NewFlagSet("signer", flag.ContinueOnError)
+	listen := flags.String("listen", "127.0.0.1:8700", "address to listen on")
+	token := flags.String("token", os.Getenv(signer_token_env), "bearer token required from the sniper (default $"+signer_token_env+")")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	// without a token only local processes may ask for signatures
+	host, _, err := net.SplitHostPort(*listen)
+	if err != nil {
+		color.Warn.Tips("wrong listen address " + *listen)
+		return 2
+	}
+	if ip := net.ParseIP(host); *token == "" && host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		color.Warn.Tips("a token is required to listen on " + *listen)
+		return 2
+	}
+
+	wallets, err := keystore_wallets()
+	if err != nil {
+		color.Warn.Tips(err.Error())
+		return 1
+	}
+
+	server := &signer_server_struct{token: *token, wallets: wallets}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/keys", server.keys)
+	mux.HandleFunc("/sign", server.sign)
+
+	for name, wallet := range wallets {
+		fmt.Printf("%s %s %s\n", color.Magenta.Text(fmt.Sprintf("%-10s", name)), wallet.address_str, color.Gray.Text(wallet.publicKeyStr))
+	}
+	fmt.Printf("%s %s\n", color.Magenta.Text("Listen    "), *listen)
+
+	if err := http.ListenAndServe(*listen, mux); err != nil {
+		color.Warn.Tips(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (server *signer_server_struct) authorized(r *http.Request) bool {
+	if server.token == "" {
+		return true
+	}
+
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+server.token)) == 1
+}
+
+// keys lists the public keys, the zero signature lets the sniper simulate without a round trip
+func (server *signer_server_struct) keys(w http.ResponseWriter, r *http.Request) {
+
+	if !server.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Method != "GET" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	keys := map[string]remote_key_struct{}
+	for name, wallet := range server.wallets {
+		zero := wallet.zero_authenticator()
+
+		scheme := "ed25519"
+		if zero.variant == authenticator_multi_ed25519 {
+			scheme = "multi_ed25519"
+		}
+
+		keys[name] = remote_key_struct{
+			Scheme:               scheme,
+			Public_key:           fmt.Sprintf("0x%x", zero.public_key),
+			Simulation_signature: fmt.Sprintf("0x%x", zero.signature),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(keys)
+}
+
+// sign signs transaction signing messages only, never arbitrary data
+func (server *signer_server_struct) sign(w http.ResponseWriter, r *http.Request) {
+
+	if !server.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Method != "POST" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request remote_sign_request_struct
+	body, _ := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+	if err := json.Unmarshal(body, &request); err != nil {
+		http.Error(w, "wrong request", http.StatusBadRequest)
+		return
+	}
+
+	wallet, ok := server.wallets[request.Key]
+	if !ok {
+		http.Error(w, "unknown key "+request.Key, http.StatusNotFound)
+		return
+	}
+
+	message, err := decode_hex(request.Message)
+	if err != nil || !signing_message_prefix(message) {
+		http.Error(w, "not a transaction signing message", http.StatusBadRequest)
+		return
+	}
+
+	authenticator, err := wallet.sign(message)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("[%s] [%s] %s\n",
+		color.Magenta.Text(strings.Split(time.Now().String(), " ")[1][:12]),
+		color.Yellow.Text("INFO   "),
+		fmt.Sprintf("Signed for %s %s, request from %s", request.Key, wallet.address_str, r.RemoteAddr),
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"signature": fmt.Sprintf("0x%x", authenticator.signature),
+	})
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestKeystoreSigner(t *testing.T) {
+
+	local := test_wallet(t, test_seed_1)
+	sealed := test_wallet(t, test_seed_1)
+	if err := sealed.seal(); err != nil {
+		t.Fatal(err)
+	}
+
+	if sealed.privateKey != nil || sealed.privateKeyStr != "" {
+		t.Error("sealed wallet still holds the private key")
+	}
+	if sealed.address_str != local.address_str {
+		t.Errorf("address %s, want %s", sealed.address_str, local.address_str)
+	}
+
+	raw := test_raw_transaction(t, sealed.address, test_transfer_payload())
+
+	want, _ := local.sign(raw.signing_message())
+	got, err := sealed.sign(raw.signing_message())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ed25519 is deterministic, both sign the golden transaction alike
+	if !bytes.Equal(got.signature, want.signature) || !bytes.Equal(got.public_key, want.public_key) || !got.verify(raw.signing_message()) {
+		t.Errorf("sealed signature %x, want %x", got.signature, want.signature)
+	}
+	expect_hex(t, "signed transaction", raw.signed_transaction(got, nil), test_signed_entry)
+
+	zero := sealed.zero_authenticator()
+	if !bytes.Equal(zero.public_key, want.public_key) || !bytes.Equal(zero.signature, make([]byte, 64)) {
+		t.Errorf("zero authenticator %x %x", zero.public_key, zero.signature)
+	}
+}
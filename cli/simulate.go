@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/gookit/color"
+	"github.com/paulrademacher/climenu"
+)
+
+/*
+--------------------Simulation--------------------
+*/
+type simulation_struct struct {
+	success   bool
+	vm_status string
+	gas_used  uint64
+}
+
+// simulate_transaction runs the unsigned transaction on the node, signature must be zeroed
+func simulate_transaction(Config *config_struct, thx map[string]interface{}, signature map[string]interface{}) (simulation_struct, error) {
+
+	var simulation simulation_struct
+
+	simulated := map[string]interface{}{}
+	for key, value := range thx {
+		simulated[key] = value
+	}
+	simulated["signature"] = signature
+
+	txn_request, _ := json.Marshal(simulated)
+	req, _ := http.NewRequest("POST", Config.node().simulate, bytes.NewReader(txn_request))
+	req.Header.Add("Content-Type", "application/json")
+
+	res, err := node_client.Do(req)
+	if err != nil {
+		return simulation, errors.New("simulation: node request error")
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return simulation, errors.New("simulation: error read body")
+	}
+
+	if res.StatusCode != 200 {
+		var response struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(body, &response)
+
+		return simulation, fmt.Errorf("simulation: %s %s", res.Status, response.Message)
+	}
+
+	var response []struct {
+		Success   bool   `json:"success"`
+		Vm_status string `json:"vm_status"`
+		Gas_used  string `json:"gas_used"`
+	}
+	if err = json.Unmarshal(body, &response); err != nil || len(response) == 0 {
+		return simulation, errors.New("simulation: error decode body")
+	}
+
+	simulation.success = response[0].Success
+	simulation.vm_status = response[0].Vm_status
+	simulation.gas_used, _ = strconv.ParseUint(response[0].Gas_used, 10, 64)
+
+	return simulation, nil
+}
+
+// doomed reports aborts caused by the listing, sequence errors are expected for parallel buys
+func (simulation simulation_struct) doomed() bool {
+	if simulation.success {
+		return false
+	}
+
+	too_old, too_new := sequence_number_error(simulation.vm_status)
+	return !too_old && !too_new
+}
+
+// simulated_max_gas leaves headroom over the simulated gas usage
+func simulated_max_gas(gas_used uint64, max_gas_amount uint64) uint64 {
+	if gas_used == 0 {
+		return max_gas_amount
+	}
+
+	sized := gas_used * 3 / 2
+	if sized < gas_used+100 {
+		sized = gas_used + 100
+	}
+	if sized > max_gas_amount {
+		return max_gas_amount
+	}
+
+	return sized
+}
+
+func ask_simulate(Config *config_struct) bool {
+
+	menu := climenu.NewButtonMenu("", "Simulate before submit")
+	menu.AddMenuItem("No", "false")
+	menu.AddMenuItem("Yes", "true")
+
+	simulate, escaped := menu.Run()
+	if escaped {
+		return false
+	}
+
+	Clear(3, nil, nil)
+
+	Config.session.simulate = simulate == "true"
+	fmt.Printf("%s %t\n", color.Magenta.Text("Simulate  "), Config.session.simulate)
+
+	return true
+}
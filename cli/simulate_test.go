@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSimulateTransaction(t *testing.T) {
+
+	var request map[string]interface{}
+	respond := `[{"success":true,"vm_status":"Executed successfully","gas_used":"532"}]`
+	status := 200
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/transactions/simulate" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("request %s %s", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &request)
+
+		w.WriteHeader(status)
+		w.Write([]byte(respond))
+	}))
+	defer server.Close()
+
+	Config := test_broadcast_config(server)
+	thx := map[string]interface{}{"sender": "0x1", "sequence_number": "7"}
+	signature := map[string]interface{}{"type": "ed25519_signature", "signature": "0x00"}
+
+	simulation, err := simulate_transaction(Config, thx, signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !simulation.success || simulation.gas_used != 532 || simulation.vm_status != "Executed successfully" {
+		t.Errorf("simulation %+v", simulation)
+	}
+
+	// the zero signature is added to a copy, the transaction to sign is untouched
+	if request["signature"] == nil || request["sequence_number"] != "7" {
+		t.Errorf("simulated request %v", request)
+	}
+	if _, ok := thx["signature"]; ok {
+		t.Error("simulate_transaction modified the transaction")
+	}
+
+	respond = `[{"success":false,"vm_status":"Move abort in 0x2c7b::marketplace_v2: ELISTING_NOT_FOUND(0x60001)","gas_used":"12"}]`
+	simulation, err = simulate_transaction(Config, thx, signature)
+	if err != nil || simulation.success || !simulation.doomed() {
+		t.Errorf("aborted simulation %+v %v, want doomed", simulation, err)
+	}
+
+	status, respond = 400, `{"message":"Invalid transaction: INVALID_SIGNATURE"}`
+	if _, err = simulate_transaction(Config, thx, signature); err == nil {
+		t.Error("400: expected error")
+	}
+
+	status, respond = 200, `[]`
+	if _, err = simulate_transaction(Config, thx, signature); err == nil {
+		t.Error("empty response: expected error")
+	}
+}
+
+func TestSimulationDoomed(t *testing.T) {
+
+	tests := []struct {
+		simulation simulation_struct
+		doomed     bool
+	}{
+		{simulation_struct{success: true}, false},
+		{simulation_struct{vm_status: "Move abort in 0x1::coin: EINSUFFICIENT_BALANCE(0x10006)"}, true},
+		{simulation_struct{vm_status: "OUT_OF_GAS"}, true},
+		// parallel buys of the same wallet run ahead of the on-chain sequence number
+		{simulation_struct{vm_status: "SEQUENCE_NUMBER_TOO_NEW"}, false},
+		{simulation_struct{vm_status: "SEQUENCE_NUMBER_TOO_OLD"}, false},
+	}
+
+	for _, test := range tests {
+		if got := test.simulation.doomed(); got != test.doomed {
+			t.Errorf("%+v: doomed %t, want %t", test.simulation, got, test.doomed)
+		}
+	}
+}
+
+func TestSimulatedMaxGas(t *testing.T) {
+
+	tests := []struct {
+		gas_used uint64
+		max_gas  uint64
+		want     uint64
+	}{
+		{0, 2000, 2000},    // nothing simulated keeps the configured max
+		{1, 2000, 101},     // +100 above 1.5x on small amounts
+		{150, 2000, 250},   // 225 < 250
+		{200, 2000, 300},   // 1.5x equals +100
+		{532, 2000, 798},   // 1.5x
+		{1000, 1200, 1200}, // capped by the configured max
+		{3000, 2000, 2000},
+	}
+
+	for _, test := range tests {
+		if got := simulated_max_gas(test.gas_used, test.max_gas); got != test.want {
+			t.Errorf("simulated_max_gas(%d, %d) = %d, want %d", test.gas_used, test.max_gas, got, test.want)
+		}
+	}
+}
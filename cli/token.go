@@ -0,0 +1,259 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+/*
+--------------------Token standard--------------------
+*/
+const (
+	token_v1 = "v1" // 0x3::token, bought by creator, collection and name
+	token_v2 = "v2" // 0x4::token digital asset objects, bought by listing object
+)
+
+// collection_v2_address is the named collection object address, sha3(creator || name || 0xFE)
+func collection_v2_address(creator string, name string) string {
+	address, _ := parse_address(creator)
+
+	data := append(address[:], []byte(name)...)
+	data = append(data, 0xFE)
+
+	return fmt.Sprintf("0x%x", sha3.Sum256(data))
+}
+
+// detect_token_version looks for a token v2 collection object, then for the token v1 collection store of the creator
+func detect_token_version(client client_struct, creator string, name string) (string, error) {
+
+	status, _, err := node_get(client.accounts + collection_v2_address(creator, name) + "/resource/0x4::collection::Collection")
+	if err != nil {
+		return "", err
+	}
+	if status == 200 {
+		return token_v2, nil
+	}
+
+	status, _, err = node_get(client.accounts + creator + "/resource/0x3::token::Collections")
+	if err != nil {
+		return "", err
+	}
+	if status == 200 {
+		return token_v1, nil
+	}
+
+	return "", errors.New("collection not found as token v1 or v2")
+}
+
+// token_version detects the standard of the collection, the last known one is kept when the node can't tell
+func (Config *config_struct) token_version(collection_info *collection_info_struct) string {
+
+	version, err := detect_token_version(Config.node(), collection_info.Creator, collection_info.Name)
+	if err == nil {
+		collection_info.Version = version
+	}
+	if collection_info.Version == "" {
+		collection_info.Version = token_v1
+	}
+
+	return collection_info.Version
+}
+
+// topaz_property_version takes the property version from a token v1 id, creator::collection::name::version
+func topaz_property_version(token_id string) string {
+
+	i := strings.LastIndex(token_id, "::")
+	if i == -1 || i+2 == len(token_id) {
+		return "0"
+	}
+
+	version := token_id[i+2:]
+	for _, c := range version {
+		if c < '0' || c > '9' {
+			return "0"
+		}
+	}
+
+	return version
+}
+
+// v2_listing_token checks the listing_id and token_id of a token v2 listing from the marketplace api and returns the token object address
+func v2_listing_token(marketplace string, listing_id string, token_id string) (string, error) {
+
+	if listing_id == "" || token_id == "" {
+		return "", fmt.Errorf("%s: token v2 listing without listing_id or token_id, the api fields may have changed", marketplace)
+	}
+	if _, err := parse_address(listing_id); err != nil {
+		return "", fmt.Errorf("%s: listing_id %q is not an object address", marketplace, listing_id)
+	}
+	if _, err := parse_address(token_id); err != nil {
+		return "", fmt.Errorf("%s: token_id %q is not an object address", marketplace, token_id)
+	}
+
+	return token_id, nil
+}
+
+/*
+----------Payloads----------
+*/
+func topaz_payload(Config *config_struct, nft_info nft_info, seller string, listing string) (payload_struct, error) {
+
+	if nft_info.token_address == "" {
+		return payload_struct{
+			Type:     "entry_function_payload",
+			Function: Config.network().Contracts.Topaz + "::marketplace_v2::buy",
+			TypeArguments: []string{
+				aptos_coin,
+			},
+			Arguments: []string{
+				seller,
+				fmt.Sprintf("%d", int(nft_info.price)),
+				"1",
+				nft_info.creator,
+				nft_info.collection,
+				nft_info.token_name,
+				nft_info.property_version,
+			},
+			ArgumentTypes: []string{
+				"address",
+				"u64",
+				"u64",
+				"address",
+				"0x1::string::String",
+				"0x1::string::String",
+				"u64",
+			},
+		}, nil
+	}
+
+	if listing == "" {
+		return payload_struct{}, errors.New("topaz: token v2 listing without listing object")
+	}
+
+	return payload_struct{
+		Type:     "entry_function_payload",
+		Function: Config.network().Contracts.Topaz_v2,
+		TypeArguments: []string{
+			aptos_coin,
+		},
+		Arguments: []string{
+			listing,
+		},
+		ArgumentTypes: []string{
+			"0x1::object::Object<0x1::object::ObjectCore>",
+		},
+	}, nil
+}
+
+func bluemove_payload(Config *config_struct, nft_info nft_info, listing string) (payload_struct, error) {
+
+	if nft_info.token_address == "" {
+		return bluemove_batch_payload(Config, nft_info), nil
+	}
+
+	if listing == "" {
+		return payload_struct{}, errors.New("bluemove: token v2 listing without listing object")
+	}
+
+	return payload_struct{
+		Type:          "entry_function_payload",
+		Function:      Config.network().Contracts.Bluemove_v2,
+		TypeArguments: []string{},
+		Arguments: [][]string{
+			{
+				listing,
+			},
+			{
+				fmt.Sprintf("%d", int(nft_info.price)),
+			},
+		},
+		ArgumentTypes: []string{
+			"vector<address>",
+			"vector<u64>",
+		},
+	}, nil
+}
+
+// bluemove_batch_payload buys token v1 listings with the batch function of bluemove
+func bluemove_batch_payload(Config *config_struct, listings ...nft_info) payload_struct {
+
+	arguments := [][]string{{}, {}, {}, {}}
+	for _, listing := range listings {
+		arguments[0] = append(arguments[0], listing.creator)
+		arguments[1] = append(arguments[1], listing.collection)
+		arguments[2] = append(arguments[2], listing.token_name)
+		arguments[3] = append(arguments[3], fmt.Sprintf("%d0", int(listing.price)))
+	}
+
+	return payload_struct{
+		Type:          "entry_function_payload",
+		Function:      Config.network().Contracts.Bluemove + "::marketplaceV2::batch_buy_script",
+		TypeArguments: []string{},
+		Arguments:     arguments,
+		ArgumentTypes: []string{
+			"vector<address>",
+			"vector<0x1::string::String>",
+			"vector<0x1::string::String>",
+			"vector<u64>",
+		},
+	}
+}
+
+// check_v2_payload compares the configured token v2 function with its module abi, the shapes of
+// the listing arguments are assumptions about the marketplace contract
+func check_v2_payload(client client_struct, payload payload_struct) error {
+
+	abi, err := fetch_function_abi(client, payload.Function)
+	if err != nil {
+		return err
+	}
+
+	if len(abi.Generic_type_params) != len(payload.TypeArguments) {
+		return fmt.Errorf("abi: %s expects %d type arguments, the sniper sends %d", payload.Function, len(abi.Generic_type_params), len(payload.TypeArguments))
+	}
+
+	params := abi.argument_types(payload.TypeArguments)
+	mismatch := len(params) != len(payload.ArgumentTypes)
+	for i := 0; !mismatch && i < len(params); i++ {
+		mismatch = object_as_address(params[i]) != object_as_address(payload.ArgumentTypes[i])
+	}
+	if mismatch {
+		return fmt.Errorf("abi: %s takes (%s), the sniper sends (%s)", payload.Function, strings.Join(params, ", "), strings.Join(payload.ArgumentTypes, ", "))
+	}
+
+	return nil
+}
+
+// object_as_address replaces 0x1::object::Object<T> with address, both encode the same
+func object_as_address(move_type string) string {
+
+	const object = "0x1::object::Object<"
+
+	for {
+		start := strings.Index(move_type, object)
+		if start == -1 {
+			return move_type
+		}
+
+		depth, end := 0, start+len(object)-1
+		for ; end < len(move_type); end++ {
+			if move_type[end] == '<' {
+				depth++
+			}
+			if move_type[end] == '>' {
+				depth--
+				if depth == 0 {
+					break
+				}
+			}
+		}
+		if end == len(move_type) {
+			return move_type
+		}
+
+		move_type = move_type[:start] + "address" + move_type[end+1:]
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestObjectAsAddress(t *testing.T) {
+
+	tests := map[string]string{
+		"0x1::object::Object<0x1::object::ObjectCore>":   "address",
+		"vector<0x1::object::Object<0x4::token::Token>>": "vector<address>",
+		"0x1::object::Object<0x1::option::Option<u64>>":  "address",
+		"0x1::string::String":                            "0x1::string::String",
+		"0x1::object::Object<0x1::object::ObjectCore":    "0x1::object::Object<0x1::object::ObjectCore",
+	}
+
+	for move_type, want := range tests {
+		if got := object_as_address(move_type); got != want {
+			t.Errorf("%s: %s, want %s", move_type, got, want)
+		}
+	}
+}
+
+func TestCheckV2Payload(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"abi":{"exposed_functions":[
+			{"name":"purchase","is_entry":true,"generic_type_params":[{"constraints":[]}],"params":["&signer","0x1::object::Object<0xabc::listing::Listing>"]},
+			{"name":"batch_buy","is_entry":true,"generic_type_params":[],"params":["&signer","vector<0x1::object::Object<0xabc::listing::Listing>>","vector<u64>"]},
+			{"name":"buy_with_fee","is_entry":true,"generic_type_params":[],"params":["&signer","address","u64","u64"]}
+		]}}`))
+	}))
+	defer server.Close()
+
+	client := new_client(server.URL+"/v1", 1)
+	listing := nft_info{token_address: "0x1", price: 100}
+
+	Config := &config_struct{}
+	Config.Networks = map[string]network_profile_struct{"mainnet": {Contracts: contracts_struct{
+		Topaz_v2:    "0xabc::coin_listing::purchase",
+		Bluemove_v2: "0xabc::marketplace::batch_buy",
+	}}}
+
+	payload, _ := topaz_payload(Config, listing, "", "0x1")
+	if err := check_v2_payload(client, payload); err != nil {
+		t.Errorf("topaz: %s", err)
+	}
+
+	payload, _ = bluemove_payload(Config, listing, "0x1")
+	if err := check_v2_payload(client, payload); err != nil {
+		t.Errorf("bluemove: %s", err)
+	}
+
+	// a function taking other arguments is refused before sniping
+	Config.Networks["mainnet"] = network_profile_struct{Contracts: contracts_struct{Bluemove_v2: "0xabc::marketplace::buy_with_fee"}}
+	payload, _ = bluemove_payload(Config, listing, "0x1")
+	if err := check_v2_payload(client, payload); err == nil {
+		t.Error("buy_with_fee: expected argument mismatch")
+	}
+
+	Config.Networks["mainnet"] = network_profile_struct{Contracts: contracts_struct{Topaz_v2: "0xabc::marketplace::buy_with_fee"}}
+	payload, _ = topaz_payload(Config, listing, "", "0x1")
+	if err := check_v2_payload(client, payload); err == nil {
+		t.Error("topaz buy_with_fee: expected type argument mismatch")
+	}
+}
+
+func TestSweepPayload(t *testing.T) {
+
+	Config := &config_struct{}
+	listings := []nft_info{
+		{marketplace: "bluemove", creator: "0x1", collection: "bears", token_name: "bear #1", price: 10_000_000},
+		{marketplace: "bluemove", creator: "0x1", collection: "bears", token_name: "bear #2", price: 20_000_000},
+	}
+
+	payload, err := sweep_payload(Config, listings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload.Function != network_defaults["mainnet"].Contracts.Bluemove+"::marketplaceV2::batch_buy_script" {
+		t.Errorf("function %s", payload.Function)
+	}
+
+	arguments := payload.Arguments.([][]string)
+	if len(arguments[2]) != 2 || arguments[2][1] != "bear #2" || arguments[3][0] != "100000000" {
+		t.Errorf("arguments %v", arguments)
+	}
+
+	// each sniper only collects the listings of its own marketplace
+	listings[1].marketplace = "topaz"
+	if _, err := sweep_payload(Config, listings); err == nil {
+		t.Error("mixed marketplaces: expected error")
+	}
+}
+
+func TestV2ListingToken(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		listing_id string
+		token_id   string
+		fails      bool
+	}{
+		{"object addresses", "0x5678", "0x9abc", false},
+		// the api may rename the fields, an empty one is never replaced by a derived address
+		{"no listing_id", "", "0x9abc", true},
+		{"no token_id", "0x5678", "", true},
+		// a token v1 id in token_id is not an object
+		{"token v1 id", "0x5678", "0xbeef::Bruh Bears::Bruh Bear #1::0", true},
+		{"bad listing_id", "listing-1", "0x9abc", true},
+	}
+
+	for _, test := range tests {
+		token_address, err := v2_listing_token("topaz", test.listing_id, test.token_id)
+		if (err != nil) != test.fails {
+			t.Errorf("%s: %v, want fails %t", test.name, err, test.fails)
+		}
+		if !test.fails && token_address != test.token_id {
+			t.Errorf("%s: %s, want %s", test.name, token_address, test.token_id)
+		}
+	}
+}
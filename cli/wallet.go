@@ -0,0 +1,261 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gookit/color"
+	"github.com/paulrademacher/climenu"
+	"golang.org/x/crypto/sha3"
+)
+
+/*
+--------------------Wallet--------------------
+*/
+
+// new_wallet derives keys and address from a 64 or 66 char hex seed
+func new_wallet(key string) (wallet_struct, error) {
+
+	switch len(key) {
+	case 64:
+	case 66:
+		key = key[2:]
+	default:
+		return wallet_struct{}, errors.New("wrong private key length")
+	}
+
+	seed, err := hex.DecodeString(key)
+	if err != nil {
+		return wallet_struct{}, errors.New("wrong private key hex")
+	}
+
+	privateKey := ed25519.NewKeyFromSeed(seed[:])
+
+	publicKey := privateKey.Public().(ed25519.PublicKey)
+
+	wallet := signer_wallet(local_signer_struct{private_key: privateKey}, authenticator_ed25519, publicKey)
+	wallet.privateKey = privateKey
+	wallet.publicKey = publicKey
+	wallet.privateKeyStr = fmt.Sprintf("0x%x", privateKey)
+
+	return wallet, nil
+}
+
+// signer_wallet derives the authentication key from the public key, the scheme byte equals the authenticator variant
+func signer_wallet(signer signer_struct, variant uint64, public_key []byte) wallet_struct {
+
+	data := append(append([]byte{}, public_key...), byte(variant))
+	authKey := sha3.Sum256(data)
+
+	return wallet_struct{
+		balance:      &balance_struct{},
+		publicKeyStr: fmt.Sprintf("0x%x", public_key),
+		auth_key:     authKey,
+		address:      authKey,
+		address_str:  fmt.Sprintf("0x%x", authKey),
+		signer:       signer,
+	}
+}
+
+// use_address switches to the configured account and checks its on-chain authentication key matches the private key
+func (wallet *wallet_struct) use_address(client client_struct, address string) error {
+
+	configured := address != ""
+	if configured {
+		account, err := parse_address(address)
+		if err != nil {
+			return errors.New("wrong aptos_account_address: " + err.Error())
+		}
+		wallet.address = account
+		wallet.address_str = fmt.Sprintf("0x%x", account)
+	}
+
+	status, body, err := node_get(client.accounts + wallet.address_str)
+	if err != nil {
+		return errors.New("account: " + err.Error())
+	}
+
+	if status == 404 {
+		// a new account derived from the key is created by its first transfer
+		if configured && wallet.address != wallet.auth_key {
+			return fmt.Errorf("account %s not found on chain, check aptos_account_address", wallet.address_str)
+		}
+		return nil
+	}
+
+	var account struct {
+		Authentication_key string `json:"authentication_key"`
+	}
+	if err = json.Unmarshal(body, &account); err != nil {
+		return errors.New("account: error decode account")
+	}
+
+	on_chain, err := parse_address(account.Authentication_key)
+	if err != nil {
+		return errors.New("account: error decode authentication key")
+	}
+
+	if on_chain != wallet.auth_key {
+		if configured {
+			return fmt.Errorf("authentication key mismatch: account %s has authentication key %s, the private key gives 0x%x, so it does not control this account",
+				wallet.address_str, account.Authentication_key, wallet.auth_key)
+		}
+		return fmt.Errorf("authentication key mismatch: the key of account %s was rotated to %s. If this key now controls another account, set its address in aptos_account_address",
+			wallet.address_str, account.Authentication_key)
+	}
+
+	return nil
+}
+
+func (wallet *wallet_struct) sign(message []byte) (account_authenticator_struct, error) {
+	return wallet.signer.sign(message)
+}
+
+// zero_authenticator is accepted by simulation only
+func (wallet *wallet_struct) zero_authenticator() account_authenticator_struct {
+	return wallet.signer.zero_authenticator()
+}
+
+/*
+----------MultiEd25519----------
+*/
+type multi_ed25519_struct struct {
+	public_keys  []ed25519.PublicKey
+	private_keys map[int]ed25519.PrivateKey // index in public_keys, at least threshold of them
+	threshold    uint8
+}
+
+// new_multi_wallet loads a MultiEd25519 account, public_keys is the full ordered key set, empty when every private key is given
+func new_multi_wallet(keys []string, public_keys []string, threshold uint8) (wallet_struct, error) {
+
+	multi := &multi_ed25519_struct{private_keys: map[int]ed25519.PrivateKey{}, threshold: threshold}
+
+	var private_keys []ed25519.PrivateKey
+	for i, key := range keys {
+		wallet, err := new_wallet(key)
+		if err != nil {
+			return wallet_struct{}, fmt.Errorf("multisig key %d: %s", i, err.Error())
+		}
+		private_keys = append(private_keys, wallet.privateKey)
+	}
+
+	if len(public_keys) == 0 {
+		for _, private_key := range private_keys {
+			multi.public_keys = append(multi.public_keys, private_key.Public().(ed25519.PublicKey))
+		}
+	}
+	for i, public_key := range public_keys {
+		raw, err := hex.DecodeString(strings.TrimPrefix(public_key, "0x"))
+		if err != nil || len(raw) != ed25519.PublicKeySize {
+			return wallet_struct{}, fmt.Errorf("multisig public key %d: wrong public key", i)
+		}
+		multi.public_keys = append(multi.public_keys, ed25519.PublicKey(raw))
+	}
+
+	if len(multi.public_keys) > 32 {
+		return wallet_struct{}, errors.New("multisig: at most 32 public keys")
+	}
+	if threshold == 0 || int(threshold) > len(multi.public_keys) {
+		return wallet_struct{}, fmt.Errorf("multisig: threshold must be between 1 and %d", len(multi.public_keys))
+	}
+
+	for i, private_key := range private_keys {
+		index := -1
+		for j, public_key := range multi.public_keys {
+			if public_key.Equal(private_key.Public()) {
+				index = j
+				break
+			}
+		}
+		if index == -1 {
+			return wallet_struct{}, fmt.Errorf("multisig key %d: not in public_keys", i)
+		}
+		multi.private_keys[index] = private_key
+	}
+	if len(multi.private_keys) < int(threshold) {
+		return wallet_struct{}, fmt.Errorf("multisig: %d of %d signatures needed, %d keys given", threshold, len(multi.public_keys), len(multi.private_keys))
+	}
+
+	return signer_wallet(multi, authenticator_multi_ed25519, multi.public_key()), nil
+}
+
+// public_key is the MultiEd25519PublicKey, public keys || threshold
+func (multi *multi_ed25519_struct) public_key() []byte {
+	var data []byte
+	for _, public_key := range multi.public_keys {
+		data = append(data, public_key...)
+	}
+
+	return append(data, multi.threshold)
+}
+
+func (multi *multi_ed25519_struct) sign(message []byte) (account_authenticator_struct, error) {
+	return multi.signatures(message, false), nil
+}
+
+func (multi *multi_ed25519_struct) zero_authenticator() account_authenticator_struct {
+	return multi.signatures(nil, true)
+}
+
+// signatures signs with the first threshold keys, signatures ordered by key index with their bits set in the bitmap
+func (multi *multi_ed25519_struct) signatures(message []byte, zero bool) account_authenticator_struct {
+
+	var signatures []byte
+	var bitmap [4]byte
+
+	signed := 0
+	for index := range multi.public_keys {
+		private_key, ok := multi.private_keys[index]
+		if !ok {
+			continue
+		}
+
+		if zero {
+			signatures = append(signatures, make([]byte, ed25519.SignatureSize)...)
+		} else {
+			signatures = append(signatures, ed25519.Sign(private_key, message)...)
+		}
+		bitmap[index/8] |= 0x80 >> (index % 8)
+
+		if signed++; signed == int(multi.threshold) {
+			break
+		}
+	}
+
+	return account_authenticator_struct{
+		variant:    authenticator_multi_ed25519,
+		public_key: multi.public_key(),
+		signature:  append(signatures, bitmap[:]...),
+	}
+}
+
+/*
+----------Gas wallet----------
+*/
+func ask_fee_payer(Config *config_struct) bool {
+
+	Config.session.fee_payer = false
+	if Config.gas_wallet == nil {
+		return true
+	}
+
+	menu := climenu.NewButtonMenu("", "Pay gas from gas wallet ["+Config.gas_wallet.address_str+"]")
+	menu.AddMenuItem("Yes", "true")
+	menu.AddMenuItem("No", "false")
+
+	fee_payer, escaped := menu.Run()
+	if escaped {
+		return false
+	}
+
+	Clear(3, nil, nil)
+
+	Config.session.fee_payer = fee_payer == "true"
+	fmt.Printf("%s %t\n", color.Magenta.Text("Gas wallet"), Config.session.fee_payer)
+
+	return true
+}
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gookit/color"
+	"github.com/paulrademacher/climenu"
+)
+
+/*
+--------------------Wallets--------------------
+*/
+type wallet_row_struct struct {
+	name    string
+	address string
+}
+
+func wallets(Config *config_struct) {
+
+	for {
+		Clear(4, "action > wallets", "info")
+
+		menu := climenu.NewButtonMenu("", "Choose action")
+		menu.AddMenuItem("List wallets", "list")
+		menu.AddMenuItem("Generate wallet", "generate")
+		menu.AddMenuItem("Import wallet", "import")
+
+		action, escaped := menu.Run()
+		if escaped {
+			return
+		}
+
+		switch action {
+		case "list":
+			Clear(4, "action > wallets > list", "info")
+			print_wallets(Config.node(), Config.wallet_rows())
+
+			color.Grayf("Press enter for back")
+			fmt.Scanln()
+
+		case "generate", "import":
+			Clear(4, "action > wallets > "+action, "info")
+
+			name := strings.TrimSpace(climenu.GetText("Wallet name", fmt.Sprintf("eg: wallet-%d", len(Config.stored_wallets)+1)))
+			Clear(1, nil, nil)
+
+			key := ""
+			if action == "import" {
+				key = read_secret("Private key")
+			}
+
+			wallet, err := Config.store_wallet(name, key)
+			if err != nil {
+				color.Warn.Tips(err.Error() + ". Press enter for back.")
+				fmt.Scanln()
+				continue
+			}
+
+			fmt.Printf("%s %s\n", color.Magenta.Text("Wallet    "), wallet.name)
+			fmt.Printf("%s %s\n", color.Magenta.Text("Address   "), wallet.address)
+
+			color.Warn.Tips("Saved to the keystore, fund the address and restart to snipe with it. Press enter for back.")
+			fmt.Scanln()
+		}
+	}
+}
+
+// store_wallet generates a key when key is empty, checks it and writes it into the keystore, an empty name defaults to wallet-N
+func (Config *config_struct) store_wallet(name string, key string) (wallet_row_struct, error) {
+
+	if key == "" {
+		_, private_key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return wallet_row_struct{}, fmt.Errorf("error generate key: %s", err.Error())
+		}
+		key = fmt.Sprintf("0x%x", private_key.Seed())
+	}
+
+	wallet, err := new_wallet(strings.TrimSpace(key))
+	if err != nil {
+		return wallet_row_struct{}, err
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(os.Args[0]))
+	if err != nil {
+		return wallet_row_struct{}, err
+	}
+
+	name, err = Config.add_keystore_wallet(dir, keystore_wallet_struct{Name: name, Key: fmt.Sprintf("0x%x", wallet.privateKey.Seed())})
+	if err != nil {
+		return wallet_row_struct{}, err
+	}
+
+	wallet.name = name
+	if Config.wallets != nil {
+		Config.wallets.add(&wallet)
+	}
+
+	return wallet_row_struct{name: name, address: wallet.address_str}, nil
+}
+
+// wallet_rows are the account, the gas wallet and the keystore or remote signer wallets
+func (Config *config_struct) wallet_rows() []wallet_row_struct {
+
+	rows := []wallet_row_struct{{name: "account", address: Config.wallet.address_str}}
+	if Config.gas_wallet != nil {
+		rows = append(rows, wallet_row_struct{name: "gas_wallet", address: Config.gas_wallet.address_str})
+	}
+
+	if Config.wallets != nil {
+		for _, wallet := range Config.wallets.others() {
+			rows = append(rows, wallet_row_struct{name: wallet.name, address: wallet.address_str})
+		}
+	}
+
+	return rows
+}
+
+// print_wallets prints balance and sequence number of every wallet, accounts not on chain yet have none
+func print_wallets(client client_struct, rows []wallet_row_struct) {
+
+	fmt.Printf("%-14s %-66s %14s %9s\n", "NAME", "ADDRESS", "BALANCE", "SEQUENCE")
+
+	for _, row := range rows {
+		balance := "-"
+		if octas, err := fetch_balance(client, row.address); err == nil {
+			balance = fmt.Sprintf("%f", float64(octas)/100_000_000)
+		}
+
+		sequence := "-"
+		if sequence_number, err := fetch_sequence_number(client.accounts + row.address); err == nil {
+			sequence = fmt.Sprintf("%d", sequence_number)
+		}
+
+		fmt.Printf("%-14s %-66s %14s %9s\n", row.name, row.address, balance, sequence)
+	}
+}
+
+/*
+----------Headless----------
+*/
+func headless_wallets(args []string) int {
+
+	usage := "usage: cli wallets [list|address|generate|import]\n"
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	flags := flag.NewFlagSet("wallets "+args[0], flag.ContinueOnError)
+	name := flags.String("name", "", "wallet name (default: wallet-N)")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	switch args[0] {
+	case "list":
+		var Config config_struct
+		if err := Config.load_config(); err != nil {
+			color.Warn.Tips(err.Error())
+			return 1
+		}
+
+		print_wallets(Config.node(), Config.wallet_rows())
+
+	case "address":
+		// no node needed, the account address is the one of config.json for rotated keys
+		Config, dir, err := read_config()
+		if err == nil && Config.Remote_signer.Url == "" {
+			err = Config.unlock_keystore(dir)
+		}
+		if err != nil {
+			color.Warn.Tips(err.Error())
+			return 1
+		}
+
+		wallet, gas_wallet, others, err := Config.signer_wallets()
+		if err != nil {
+			color.Warn.Tips(err.Error())
+			return 1
+		}
+		Config.wallet = wallet
+		Config.gas_wallet = gas_wallet
+		Config.wallets = new_wallet_pool(&Config.wallet, others)
+
+		if Config.Address != "" {
+			Config.wallet.address_str = Config.Address
+		}
+		if Config.gas_wallet != nil && Config.Gas_wallet.Address != "" {
+			Config.gas_wallet.address_str = Config.Gas_wallet.Address
+		}
+
+		for _, row := range Config.wallet_rows() {
+			if *name == "" || row.name == *name {
+				fmt.Printf("%-14s %s\n", row.name, row.address)
+			}
+		}
+
+	case "generate", "import":
+		Config, _, err := read_config()
+		if err != nil {
+			color.Warn.Tips(err.Error())
+			return 1
+		}
+
+		key := ""
+		if args[0] == "import" {
+			key = read_secret("Private key")
+		}
+
+		wallet, err := Config.store_wallet(*name, key)
+		if err != nil {
+			color.Warn.Tips(err.Error())
+			return 1
+		}
+
+		fmt.Printf("%-14s %s\n", wallet.name, wallet.address)
+
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n%s", args[0], usage)
+		return 2
+	}
+
+	return 0
+}
+
+/*
+----------Round robin----------
+*/
+type wallet_pool_struct struct {
+	mutex   sync.Mutex
+	wallets []*wallet_struct // account first, then the keystore or remote signer wallets
+	turn    int
+}
+
+func new_wallet_pool(account *wallet_struct, others []wallet_struct) *wallet_pool_struct {
+
+	pool := &wallet_pool_struct{wallets: []*wallet_struct{account}}
+	for i := range others {
+		pool.wallets = append(pool.wallets, &others[i])
+	}
+
+	return pool
+}
+
+// load reads balance and sequence number of the other wallets, a wallet not on chain yet stays out of the rotation
+func (pool *wallet_pool_struct) load(Config *config_struct) {
+
+	for _, wallet := range pool.others() {
+		if err := wallet.use_address(Config.node(), ""); err != nil {
+			color.Warn.Tips("wallet " + wallet.name + " skipped: " + err.Error())
+			continue
+		}
+
+		octas, err := fetch_balance(Config.node(), wallet.address_str)
+		if err != nil {
+			continue
+		}
+		wallet.balance.set(octas)
+
+		address := wallet.address_str
+		wallet.sequence, _ = new_sequence_manager(func() string {
+			return Config.node().accounts + address
+		})
+	}
+}
+
+// add lists a new wallet, it joins the rotation after a restart once funded
+func (pool *wallet_pool_struct) add(wallet *wallet_struct) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	pool.wallets = append(pool.wallets, wallet)
+}
+
+// others copies the wallets besides the account, add may grow the list meanwhile
+func (pool *wallet_pool_struct) others() []*wallet_struct {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	return append([]*wallet_struct{}, pool.wallets[1:]...)
+}
+
+// ready are the wallets with a sequence manager, the account once loaded
+func (pool *wallet_pool_struct) ready() []*wallet_struct {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	return pool.loaded()
+}
+
+func (pool *wallet_pool_struct) loaded() []*wallet_struct {
+
+	var wallets []*wallet_struct
+	for _, wallet := range pool.wallets {
+		if wallet.sequence != nil {
+			wallets = append(wallets, wallet)
+		}
+	}
+
+	return wallets
+}
+
+// next reserves amount on the next wallet in turn with enough available balance,
+// when none has it returns the next one able to cover it once purchases in flight settle, unreserved
+func (pool *wallet_pool_struct) next(amount uint64) (*wallet_struct, bool, error) {
+
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	wallets := pool.loaded()
+	if len(wallets) == 0 {
+		return nil, false, errors.New("round robin: no wallet ready")
+	}
+	start := pool.turn % len(wallets)
+
+	for i := range wallets {
+		wallet := wallets[(start+i)%len(wallets)]
+		if wallet.balance.reserve(amount, 0) {
+			pool.turn = start + i + 1
+			return wallet, true, nil
+		}
+	}
+
+	pool.turn = start + 1
+	for i := range wallets {
+		wallet := wallets[(start+i)%len(wallets)]
+		if wallet.balance.get() >= amount {
+			return wallet, false, nil
+		}
+	}
+
+	return wallets[start], false, nil
+}
+
+// ask_round_robin spreads the listings over the ready wallets, each with its own sequence numbers
+func ask_round_robin(Config *config_struct) bool {
+
+	Config.session.round_robin = false
+	if Config.wallets == nil || len(Config.wallets.ready()) < 2 {
+		return true
+	}
+
+	menu := climenu.NewButtonMenu("", fmt.Sprintf("Snipe round robin with %d wallets", len(Config.wallets.ready())))
+	menu.AddMenuItem("Yes", "true")
+	menu.AddMenuItem("No", "false")
+
+	round_robin, escaped := menu.Run()
+	if escaped {
+		return false
+	}
+
+	Clear(3, nil, nil)
+
+	Config.session.round_robin = round_robin == "true"
+	fmt.Printf("%s %t\n", color.Magenta.Text("Wallets   "), Config.session.round_robin)
+
+	return true
+}
+
+// sender is the wallet of the transaction, listings go round robin when enabled
+func (Config *config_struct) sender(nft_info nft_info, amount uint64) (*wallet_struct, bool, error) {
+
+	if Config.session.round_robin && (nft_info.mode == "sniper" || nft_info.mode == "sweep") {
+		return Config.wallets.next(amount)
+	}
+
+	return &Config.wallet, false, nil
+}
+
+// buyer is the wallet that sent the transaction of nft_info
+func (Config *config_struct) buyer(nft_info nft_info) *wallet_struct {
+	if nft_info.wallet != nil {
+		return nft_info.wallet
+	}
+
+	return &Config.wallet
+}
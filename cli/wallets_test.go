@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func test_pool_wallet(name string, octas uint64, ready bool) *wallet_struct {
+
+	wallet := &wallet_struct{name: name, balance: &balance_struct{}}
+	wallet.balance.set(octas)
+	if ready {
+		wallet.sequence = &sequence_manager_struct{}
+	}
+
+	return wallet
+}
+
+func TestWalletPoolNext(t *testing.T) {
+
+	// the account is not loaded yet, hot-2 is not on chain
+	pool := &wallet_pool_struct{wallets: []*wallet_struct{
+		test_pool_wallet("", 100, false),
+		test_pool_wallet("hot-1", 100, true),
+		test_pool_wallet("hot-2", 100, false),
+		test_pool_wallet("hot-3", 100, true),
+	}}
+
+	var names []string
+	for i := 0; i < 3; i++ {
+		wallet, reserved, err := pool.next(60)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reserved {
+			names = append(names, wallet.name)
+		} else {
+			names = append(names, "-"+wallet.name)
+		}
+	}
+
+	// both ready wallets reserve once, then the next one waits for its purchase in flight
+	if names[0] != "hot-1" || names[1] != "hot-3" || names[2] != "-hot-1" {
+		t.Errorf("turns %v", names)
+	}
+}
+
+func TestWalletPoolEmpty(t *testing.T) {
+
+	pool := &wallet_pool_struct{wallets: []*wallet_struct{test_pool_wallet("", 100, false)}}
+
+	if len(pool.ready()) != 0 {
+		t.Fatal("account without sequence manager is ready")
+	}
+	if _, _, err := pool.next(1); err == nil {
+		t.Error("expected error without a ready wallet")
+	}
+
+	Config := &config_struct{wallets: pool}
+	Config.session.round_robin = true
+	if _, _, err := Config.sender(nft_info{mode: "sniper"}, 1); err == nil {
+		t.Error("sender: expected error without a ready wallet")
+	}
+
+	// other modes always buy with the account
+	for _, mode := range []string{"minter", "custom"} {
+		if wallet, _, err := Config.sender(nft_info{mode: mode}, 1); err != nil || wallet != &Config.wallet {
+			t.Errorf("%s: sender %v %v, want the account", mode, wallet, err)
+		}
+	}
+}
+
+func TestWalletRowsWhileAdding(t *testing.T) {
+
+	Config := &config_struct{}
+	Config.wallet = wallet_struct{address_str: "0x1"}
+	Config.wallets = new_wallet_pool(&Config.wallet, nil)
+
+	// wallets generated from the menu are added while the rows are listed, go test -race checks the pool lock
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			Config.wallets.add(test_pool_wallet(fmt.Sprintf("wallet-%d", i+1), 0, false))
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		rows := Config.wallet_rows()
+		if rows[0].name != "account" {
+			t.Fatalf("first row %+v, want the account", rows[0])
+		}
+		for j, row := range rows[1:] {
+			if row.name != fmt.Sprintf("wallet-%d", j+1) {
+				t.Fatalf("row %d is %s", j+1, row.name)
+			}
+		}
+	}
+	<-done
+
+	if rows := Config.wallet_rows(); len(rows) != 101 {
+		t.Errorf("%d rows, want the account and 100 wallets", len(rows))
+	}
+}